@@ -0,0 +1,177 @@
+package hlive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// AssetsPath is the URL path Assets are served from
+const AssetsPath = "/hlive/"
+
+// AttrBundle marks the script tag that loads a Page's script bundle
+const AttrBundle = "data-hlive-bundle"
+
+const assetHashLen = 16
+
+// Assets is a registry of JavaScript that's served as fingerprinted files, which browsers can cache forever.
+//
+// A Page's scripts are served as one bundle file. The path of a bundle is made from the hashes of its scripts, so any
+// combination of registered scripts can be served. Share one Assets between all Pages and the PageServer.
+type Assets struct {
+	scripts map[string][]byte
+	mu      sync.RWMutex
+}
+
+func NewAssets() *Assets {
+	return &Assets{
+		scripts: map[string][]byte{},
+	}
+}
+
+// Add a script and get its hash
+func (a *Assets) Add(js []byte) string {
+	sum := sha256.Sum256(js)
+	hash := hex.EncodeToString(sum[:])[:assetHashLen]
+
+	a.mu.Lock()
+	a.scripts[hash] = js
+	a.mu.Unlock()
+
+	return hash
+}
+
+// Path returns the URL path for a bundle of scripts
+func (a *Assets) Path(hashes ...string) string {
+	return AssetsPath + strings.Join(hashes, "-") + ".js"
+}
+
+// ServeHTTP serves a bundle of scripts
+func (a *Assets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutPrefix(r.URL.Path, AssetsPath)
+	if !ok || !strings.HasSuffix(name, ".js") {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	bundle, ok := a.bundle(strings.TrimSuffix(name, ".js"))
+	if !ok {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+
+	for i := 0; i < len(bundle); i++ {
+		if _, err := w.Write(bundle[i]); err != nil {
+			return
+		}
+
+		if _, err := w.Write([]byte("\n;\n")); err != nil {
+			return
+		}
+	}
+}
+
+// The scripts for a bundle name. Each hash must be a script we have and only be in the name once, so a long name can't
+// make a large response.
+func (a *Assets) bundle(name string) ([][]byte, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	// More hashes than scripts means a duplicate, check before we split
+	if strings.Count(name, "-") >= len(a.scripts) {
+		return nil, false
+	}
+
+	hashes := strings.Split(name, "-")
+	seen := make(map[string]struct{}, len(hashes))
+	bundle := make([][]byte, 0, len(hashes))
+
+	for i := 0; i < len(hashes); i++ {
+		js, exists := a.scripts[hashes[i]]
+		if !exists {
+			return nil, false
+		}
+
+		if _, dup := seen[hashes[i]]; dup {
+			return nil, false
+		}
+
+		seen[hashes[i]] = struct{}{}
+		bundle = append(bundle, js)
+	}
+
+	return bundle, true
+}
+
+// PageOptionAssets loads the Page's scripts from Assets instead of inline script tags.
+// Use the same Assets for PageServer.Assets.
+func PageOptionAssets(assets *Assets) func(*Page) {
+	return func(p *Page) {
+		p.assets = assets
+	}
+}
+
+// ScriptAdd adds JavaScript to the Page, each script is only added once.
+//
+// Without Assets, an inline script tag is added to the head. With Assets, the script is added to the Page's bundle.
+func (p *Page) ScriptAdd(js []byte) {
+	if p.assets == nil {
+		sum := sha256.Sum256(js)
+		hash := hex.EncodeToString(sum[:])
+
+		p.muMeta.Lock()
+		_, exists := p.scriptsInline[hash]
+		if !exists {
+			if p.scriptsInline == nil {
+				p.scriptsInline = map[string]struct{}{}
+			}
+
+			p.scriptsInline[hash] = struct{}{}
+		}
+		p.muMeta.Unlock()
+
+		if !exists {
+			p.dom.head.Add(T("script", HTML(js)))
+		}
+
+		return
+	}
+
+	hash := p.assets.Add(js)
+
+	p.muMeta.Lock()
+	defer p.muMeta.Unlock()
+
+	for i := 0; i < len(p.scripts); i++ {
+		if p.scripts[i] == hash {
+			return
+		}
+	}
+
+	p.scripts = append(p.scripts, hash)
+}
+
+// scriptBundle is the script tag for a Page's bundle, it changes as scripts are added
+type scriptBundle struct {
+	page *Page
+}
+
+func (b scriptBundle) GetNodeContext(_ context.Context) any {
+	b.page.muMeta.RLock()
+	defer b.page.muMeta.RUnlock()
+
+	if len(b.page.scripts) == 0 {
+		return nil
+	}
+
+	// Separate so the attribute order is stable
+	return T("script", Attrs{AttrBundle: ""}, Attrs{"src": b.page.assets.Path(b.page.scripts...)})
+}
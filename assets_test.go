@@ -0,0 +1,90 @@
+package hlive_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	l "github.com/SamHennessy/hlive"
+)
+
+var bundleRegexp = regexp.MustCompile(l.AttrBundle + `="" src="(` + l.AssetsPath + `[^"]+)"`)
+
+func TestAssets_PageServer(t *testing.T) {
+	t.Parallel()
+
+	assets := l.NewAssets()
+
+	s := l.NewPageServer(func() *l.Page {
+		page := l.NewPage(l.PageOptionAssets(assets))
+		page.ScriptAdd([]byte("console.log('plugin')"))
+		page.ScriptAdd([]byte("console.log('plugin')"))
+
+		return page
+	})
+	s.Assets = assets
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	match := bundleRegexp.FindStringSubmatch(w.Body.String())
+	if match == nil {
+		t.Fatal("bundle script not rendered:", w.Body.String())
+	}
+
+	if strings.Count(match[1], "-") != 1 {
+		t.Error("expected two scripts in bundle:", match[1])
+	}
+
+	if strings.Contains(w.Body.String(), "console.log") {
+		t.Error("inline script rendered")
+	}
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, match[1], nil))
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "console.log('plugin')") {
+		t.Error("bundle not served:", w.Code)
+	}
+
+	if !strings.Contains(w.Header().Get("Cache-Control"), "immutable") {
+		t.Error("bundle not cacheable")
+	}
+
+	hash, _, _ := strings.Cut(strings.TrimPrefix(match[1], l.AssetsPath), "-")
+
+	for _, path := range []string{
+		l.AssetsPath + "missing.js",
+		// Each script once
+		l.AssetsPath + hash + "-" + hash + ".js",
+		l.AssetsPath + strings.Repeat(hash+"-", 1000) + hash + ".js",
+	} {
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Code != http.StatusNotFound {
+			t.Error("expected not found, got:", w.Code)
+		}
+	}
+}
+
+func TestAssets_ScriptAddInline(t *testing.T) {
+	t.Parallel()
+
+	page := l.NewPage()
+	page.ScriptAdd([]byte("console.log('plugin')"))
+	page.ScriptAdd([]byte("console.log('plugin')"))
+
+	buf := bytes.NewBuffer(nil)
+	if _, err := page.RunRenderPipeline(context.Background(), buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(buf.String(), "console.log('plugin')") != 1 {
+		t.Error("expected the script once:", buf.String())
+	}
+}
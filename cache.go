@@ -6,8 +6,8 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
-
 	"log/slog"
+	"time"
 
 	"github.com/vmihailenco/msgpack/v5"
 )
@@ -37,8 +37,50 @@ type Cache interface {
 	Set(key any, value any)
 }
 
+// CacheAdvanced is a Cache that can expire and remove values. HLive uses it when a Cache implements it.
+type CacheAdvanced interface {
+	Cache
+	// SetWithTTL adds a value that's removed after ttl, zero means it doesn't expire. Cost is the size in bytes.
+	SetWithTTL(key any, value any, cost int64, ttl time.Duration)
+	Delete(key any)
+}
+
+// Set with a TTL and cost when the cache supports it
+func cacheSet(cache Cache, key string, value []byte, ttl time.Duration) {
+	if ca, ok := cache.(CacheAdvanced); ok {
+		ca.SetWithTTL(key, value, int64(len(value)), ttl)
+
+		return
+	}
+
+	cache.Set(key, value)
+}
+
+// Delete when the cache supports it
+func cacheDelete(cache Cache, key string) {
+	if ca, ok := cache.(CacheAdvanced); ok {
+		ca.Delete(key)
+	}
+}
+
 // PipelineProcessorRenderHashAndCache that will cache the returned tree to support SSR
 func PipelineProcessorRenderHashAndCache(logger *slog.Logger, renderer *Renderer, cache Cache) *PipelineProcessor {
+	return PipelineProcessorRenderHashAndCacheTTL(logger, renderer, cache, 0)
+}
+
+// PipelineProcessorRenderHashAndCacheTTL is PipelineProcessorRenderHashAndCache with a TTL for caches that implement
+// CacheAdvanced
+func PipelineProcessorRenderHashAndCacheTTL(logger *slog.Logger, renderer *Renderer, cache Cache,
+	ttl time.Duration,
+) *PipelineProcessor {
+	return pipelineProcessorRenderHashAndCache(logger, renderer, func(hhash string, b []byte) {
+		cacheSet(cache, hhash, b, ttl)
+	})
+}
+
+func pipelineProcessorRenderHashAndCache(logger *slog.Logger, renderer *Renderer,
+	set func(hhash string, b []byte),
+) *PipelineProcessor {
 	pp := NewPipelineProcessor(PipelineProcessorKeyRenderer)
 
 	pp.AfterWalk = func(ctx context.Context, w io.Writer, node *NodeGroup) (*NodeGroup, error) {
@@ -58,7 +100,7 @@ func PipelineProcessorRenderHashAndCache(logger *slog.Logger, renderer *Renderer
 		if nodeBytes, err := msgpack.Marshal(node); err != nil {
 			logger.Error("PipelineProcessorRenderHashAndCache: msgpack.Marshal", "error", err)
 		} else {
-			cache.Set(hhash, nodeBytes)
+			set(hhash, nodeBytes)
 			logger.Debug("cache set", "hhash", hhash, "size", len(nodeBytes)/1024)
 		}
 
@@ -71,3 +113,24 @@ func PipelineProcessorRenderHashAndCache(logger *slog.Logger, renderer *Renderer
 
 	return pp
 }
+
+// How long a server side render with a CSRF token waits for its WebSocket connection
+const cacheConnectTTL = time.Minute
+
+// Cache a server side render. A render with a CSRF token is only used by the WebSocket connection that follows it.
+// A Cache that can't expire it would grow forever, so it's not cached and the connection will render again.
+func (p *Page) cacheSSRSet(hhash string, b []byte) {
+	ttl := p.cacheTTL
+
+	if p.cacheConnectOnly {
+		if _, ok := p.cache.(CacheAdvanced); !ok {
+			return
+		}
+
+		if ttl == 0 || ttl > cacheConnectTTL {
+			ttl = cacheConnectTTL
+		}
+	}
+
+	cacheSet(p.cache, hhash, b, ttl)
+}
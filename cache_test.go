@@ -0,0 +1,107 @@
+package hlive_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/go-test/deep"
+)
+
+type recordCache struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	cost int64
+	size int
+}
+
+func (c *recordCache) Get(_ any) (any, bool) { return nil, false }
+func (c *recordCache) Set(_, _ any)          {}
+func (c *recordCache) Delete(_ any)          {}
+
+func (c *recordCache) SetWithTTL(_ any, value any, cost int64, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, _ := value.([]byte)
+	c.size = len(b)
+	c.cost = cost
+	c.ttl = ttl
+}
+
+func TestPageOptionCacheTTL(t *testing.T) {
+	t.Parallel()
+
+	cache := &recordCache{}
+
+	s := l.NewPageServer(func() *l.Page {
+		page := l.NewPage(l.PageOptionCache(cache), l.PageOptionCacheTTL(time.Minute))
+		page.DOM().Body().Add(l.T("p", "Hello"))
+
+		return page
+	})
+
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if diff := deep.Equal(time.Minute, cache.ttl); diff != nil {
+		t.Error(diff)
+	}
+
+	if cache.size == 0 || int64(cache.size) != cache.cost {
+		t.Errorf("expected the cost to be the size: cost %d, size %d", cache.cost, cache.size)
+	}
+}
+
+type countCache struct {
+	mu   sync.Mutex
+	sets int
+}
+
+func (c *countCache) Get(_ any) (any, bool) { return nil, false }
+
+func (c *countCache) Set(_, _ any) {
+	c.mu.Lock()
+	c.sets++
+	c.mu.Unlock()
+}
+
+func TestPageServer_CSRFCache(t *testing.T) {
+	t.Parallel()
+
+	cache := &recordCache{}
+	plain := &countCache{}
+
+	for _, c := range []l.Cache{cache, plain} {
+		s := l.NewPageServer(func() *l.Page {
+			page := l.NewPage(l.PageOptionCache(c))
+			page.DOM().Body().Add(l.T("p", "Hello"))
+
+			return page
+		})
+		s.CSRFKey = []byte("test key")
+
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	// Each render is unique, so it only needs to wait for the WebSocket connection
+	if diff := deep.Equal(time.Minute, cache.ttl); diff != nil {
+		t.Error(diff)
+	}
+
+	plain.mu.Lock()
+	defer plain.mu.Unlock()
+
+	// Can't expire, so it's not used
+	if diff := deep.Equal(0, plain.sets); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,98 @@
+package hlive
+
+import (
+	"context"
+	"fmt"
+)
+
+// ComponentAsync renders a fallback while its loader runs, then renders the loader's result.
+//
+// The fallback is used for the server side render and the first WebSocket render. The loader is started when the
+// Component is mounted and is cancelled when it's unmounted or the Page is closed.
+type ComponentAsync struct {
+	*ComponentMountable
+
+	loader    func(ctx context.Context) (any, error)
+	errorFunc func(err error) any
+	content   *LockBox[any]
+}
+
+// CA is a shortcut for NewComponentAsync.
+func CA(name string, fallback any, loader func(ctx context.Context) (any, error), elements ...any) *ComponentAsync {
+	return NewComponentAsync(name, fallback, loader, elements...)
+}
+
+// NewComponentAsync is a constructor for ComponentAsync.
+//
+// The fallback and the loader's result are added after any elements.
+func NewComponentAsync(name string, fallback any, loader func(ctx context.Context) (any, error),
+	elements ...any,
+) *ComponentAsync {
+	c := &ComponentAsync{
+		ComponentMountable: NewComponentMountable(name, elements...),
+		loader:             loader,
+		content:            NewLockBox(fallback),
+	}
+
+	c.Add(c.content)
+
+	return c
+}
+
+// SetErrorFunc sets the function that creates the node to render when the loader returns an error.
+// Without one, nothing is rendered.
+func (c *ComponentAsync) SetErrorFunc(f func(err error) any) {
+	c.Tag.mu.Lock()
+	c.errorFunc = f
+	c.Tag.mu.Unlock()
+}
+
+func (c *ComponentAsync) Mount(ctx context.Context) {
+	if c == nil {
+		return
+	}
+
+	c.ComponentMountable.Mount(ctx)
+
+	Go(ctx, c, c.load)
+}
+
+func (c *ComponentAsync) load(ctx context.Context) {
+	result, err := c.runLoader(ctx)
+
+	// Unmounted or the Page closed
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err != nil {
+		LoggerDev.Error("async loader", "error", err, "name", c.GetName())
+
+		c.Tag.mu.RLock()
+		errorFunc := c.errorFunc
+		c.Tag.mu.RUnlock()
+
+		result = nil
+		if errorFunc != nil {
+			result = errorFunc(err)
+		}
+	}
+
+	c.content.Set(result)
+
+	RenderComponent(ctx, c)
+}
+
+func (c *ComponentAsync) runLoader(ctx context.Context) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("loader panic: %v", r)
+		}
+	}()
+
+	if c.loader == nil {
+		return nil, nil
+	}
+
+	return c.loader(ctx)
+}
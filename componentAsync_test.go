@@ -0,0 +1,119 @@
+package hlive_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+)
+
+func renderAsync(t *testing.T, comp *l.ComponentAsync) string {
+	t.Helper()
+
+	page := l.NewPage()
+	page.DOM().Body().Add(comp)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	ctx, rendered := renderComponentContext(ctx)
+
+	if _, err := page.RunDiffPipeline(ctx, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-rendered:
+	case <-time.After(time.Second):
+		t.Fatal("component not rendered")
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := l.NewRenderer().HTML(buf, comp); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestComponentAsync_Fallback(t *testing.T) {
+	t.Parallel()
+
+	page := l.NewPage()
+	page.DOM().Body().Add(l.CA("div", "Loading", func(ctx context.Context) (any, error) {
+		return "Loaded", nil
+	}))
+
+	buf := bytes.NewBuffer(nil)
+	if _, err := page.RunRenderPipeline(context.Background(), buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "Loading") {
+		t.Error("fallback not rendered")
+	}
+}
+
+func TestComponentAsync_Loaded(t *testing.T) {
+	t.Parallel()
+
+	comp := l.CA("div", "Loading", func(ctx context.Context) (any, error) {
+		return l.T("p", "Loaded"), nil
+	})
+
+	if html := renderAsync(t, comp); !strings.Contains(html, "<p>Loaded</p>") {
+		t.Error("result not rendered:", html)
+	}
+}
+
+func TestComponentAsync_Error(t *testing.T) {
+	t.Parallel()
+
+	for name, loader := range map[string]func(ctx context.Context) (any, error){
+		"error": func(ctx context.Context) (any, error) { return nil, errors.New("boom") },
+		"panic": func(ctx context.Context) (any, error) { panic("boom") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			comp := l.CA("div", "Loading", loader)
+			comp.SetErrorFunc(func(err error) any {
+				return "Failed"
+			})
+
+			if html := renderAsync(t, comp); !strings.Contains(html, "Failed") {
+				t.Error("error not rendered:", html)
+			}
+		})
+	}
+}
+
+func TestComponentAsync_UnmountCancels(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+
+	comp := l.CA("div", "Loading", func(ctx context.Context) (any, error) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+
+		return nil, ctx.Err()
+	})
+
+	comp.Mount(context.Background())
+	<-started
+	comp.Unmount(context.Background())
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("loader not cancelled")
+	}
+}
@@ -35,6 +35,9 @@ type ComponentMountable struct {
 	mountFunc   func(ctx context.Context)
 	unmountFunc func(ctx context.Context)
 	teardowns   []func()
+	// From Go, called when unmounted or torn down
+	cancels  map[uint64]func()
+	cancelID uint64
 }
 
 // CM is a shortcut for NewComponentMountable
@@ -74,6 +77,39 @@ func (c *ComponentMountable) Unmount(ctx context.Context) {
 	if c.unmountFunc != nil {
 		f(ctx)
 	}
+
+	c.cancelAll()
+}
+
+// addCancel adds a func that's called when unmounted or torn down, call remove when it's no longer needed
+func (c *ComponentMountable) addCancel(cancel func()) func() {
+	c.Tag.mu.Lock()
+	defer c.Tag.mu.Unlock()
+
+	if c.cancels == nil {
+		c.cancels = map[uint64]func(){}
+	}
+
+	c.cancelID++
+	id := c.cancelID
+	c.cancels[id] = cancel
+
+	return func() {
+		c.Tag.mu.Lock()
+		delete(c.cancels, id)
+		c.Tag.mu.Unlock()
+	}
+}
+
+func (c *ComponentMountable) cancelAll() {
+	c.Tag.mu.Lock()
+	cancels := c.cancels
+	c.cancels = nil
+	c.Tag.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
 }
 
 func (c *ComponentMountable) SetMount(mount func(ctx context.Context)) {
@@ -102,6 +138,8 @@ func (c *ComponentMountable) Teardown() {
 	for i := 0; i < len(teardowns); i++ {
 		teardowns[i]()
 	}
+
+	c.cancelAll()
 }
 
 // WM is a shortcut for WrapMountable.
@@ -0,0 +1,38 @@
+package hlive
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGo_RemovesCancel(t *testing.T) {
+	t.Parallel()
+
+	comp := CM("div")
+
+	for i := 0; i < 100; i++ {
+		done := make(chan struct{})
+
+		Go(context.Background(), comp, func(context.Context) {
+			close(done)
+		})
+
+		<-done
+	}
+
+	// The goroutines remove their cancel after they return
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		comp.Tag.mu.RLock()
+		n := len(comp.cancels)
+		comp.Tag.mu.RUnlock()
+
+		if n == 0 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected no cancels, got %d", n)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package hlive
+
+import (
+	"context"
+	"net/http"
+)
+
+// PageFromContext returns the Page that is rendering or handling an event
+func PageFromContext(ctx context.Context) (*Page, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	page, ok := ctx.Value(CtxPage).(*Page)
+
+	return page, ok && page != nil
+}
+
+// SessionFromContext returns the PageSession of the WebSocket connection.
+//
+// There is no session during the initial server side render.
+func SessionFromContext(ctx context.Context) (*PageSession, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	sess, ok := ctx.Value(CtxSession).(*PageSession)
+
+	return sess, ok && sess != nil
+}
+
+// SessionIDFromContext returns the ID of the PageSession of the WebSocket connection
+func SessionIDFromContext(ctx context.Context) (string, bool) {
+	sess, ok := SessionFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	return sess.GetID(), true
+}
+
+// RequestFromContext returns the HTTP request.
+//
+// For the initial server side render, this is the page request. For WebSocket work, this is the request that
+// upgraded the connection.
+func RequestFromContext(ctx context.Context) (*http.Request, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	r, ok := ctx.Value(CtxRequest).(*http.Request)
+
+	return r, ok && r != nil
+}
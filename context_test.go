@@ -0,0 +1,157 @@
+package hlive_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/gorilla/websocket"
+)
+
+func TestContext_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	var (
+		gotPage    *l.Page
+		gotRequest *http.Request
+		hasSession bool
+	)
+
+	page := l.NewPage()
+
+	pp := l.NewPipelineProcessor("test_ctx")
+	pp.BeforeWalk = func(ctx context.Context, _ io.Writer, node *l.NodeGroup) (*l.NodeGroup, error) {
+		gotPage, _ = l.PageFromContext(ctx)
+		gotRequest, _ = l.RequestFromContext(ctx)
+		_, hasSession = l.SessionFromContext(ctx)
+
+		return node, nil
+	}
+	page.PipelineSSR().Add(pp)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	page.ServeHTTP(httptest.NewRecorder(), r)
+
+	if gotPage != page {
+		t.Error("page not in context")
+	}
+
+	if gotRequest != r {
+		t.Error("request not in context")
+	}
+
+	if hasSession {
+		t.Error("unexpected session in context")
+	}
+}
+
+func TestContext_Empty(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	if _, ok := l.PageFromContext(ctx); ok {
+		t.Error("unexpected page")
+	}
+
+	if _, ok := l.SessionIDFromContext(ctx); ok {
+		t.Error("unexpected session id")
+	}
+
+	if _, ok := l.RequestFromContext(ctx); ok {
+		t.Error("unexpected request")
+	}
+}
+
+type contextValues struct {
+	page      *l.Page
+	sessionID string
+	session   bool
+	request   *http.Request
+}
+
+func readContext(ctx context.Context) contextValues {
+	var values contextValues
+
+	values.page, _ = l.PageFromContext(ctx)
+	values.sessionID, _ = l.SessionIDFromContext(ctx)
+	_, values.session = l.SessionFromContext(ctx)
+	values.request, _ = l.RequestFromContext(ctx)
+
+	return values
+}
+
+func TestContext_ServeWS(t *testing.T) {
+	t.Parallel()
+
+	got := make(chan contextValues, 2)
+	pages := make(chan *l.Page, 1)
+
+	comp := l.CM("button", l.On("click", func(ctx context.Context, _ l.Event) {
+		got <- readContext(ctx)
+	}))
+	comp.SetMount(func(ctx context.Context) {
+		got <- readContext(ctx)
+	})
+
+	s := l.NewPageServer(func() *l.Page {
+		page := l.NewPage()
+		page.DOM().Body().Add(comp)
+		pages <- page
+
+		return page
+	})
+
+	conn := dialPageServer(t, s)
+
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessID, ok := strings.CutPrefix(string(msg), "s|id|")
+	if !ok {
+		t.Fatalf("expected the session id: %s", msg)
+	}
+
+	page := <-pages
+
+	check := func(name string) {
+		t.Helper()
+
+		select {
+		case values := <-got:
+			if values.page != page {
+				t.Error(name, "page not in context")
+			}
+
+			if !values.session || values.sessionID != sessID {
+				t.Error(name, "session not in context:", values.sessionID)
+			}
+
+			if values.request == nil || values.request.URL.Query().Get("hlive") != "1" {
+				t.Error(name, "upgrade request not in context")
+			}
+		case <-time.After(time.Second):
+			t.Fatal(name, "not called")
+		}
+	}
+
+	check("mount")
+
+	if err := conn.WriteMessage(websocket.TextMessage,
+		[]byte(`{"t":"e","i":"`+comp.GetEventBindings()[0].ID+`"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	check("event")
+}
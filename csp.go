@@ -0,0 +1,79 @@
+package hlive
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"regexp"
+)
+
+const PipelineProcessorKeyScriptNonce = "hlive_script_nonce"
+
+const nonceSize = 16
+
+var nonceValid = regexp.MustCompile(`^[A-Za-z0-9+/=_-]+$`)
+
+// CSPHeader returns a Content-Security-Policy header value that only allows scripts with the nonce, and scripts
+// they load. Use it with PageServer.CSP.
+//
+//	server.CSP = hlive.CSPHeader
+func CSPHeader(nonce string) string {
+	return "script-src 'nonce-" + nonce + "' 'strict-dynamic'; object-src 'none'; base-uri 'none'"
+}
+
+// PageOptionScriptNonce sets the nonce added to every script tag
+func PageOptionScriptNonce(nonce string) func(*Page) {
+	return func(p *Page) {
+		p.SetScriptNonce(nonce)
+	}
+}
+
+// SetScriptNonce sets the nonce added to every script tag. Invalid nonce values are ignored.
+func (p *Page) SetScriptNonce(nonce string) {
+	if nonce != "" && !nonceValid.MatchString(nonce) {
+		LoggerDev.Error("invalid script nonce", "callers", CallerStackStr())
+
+		return
+	}
+
+	p.muMeta.Lock()
+	p.scriptNonce = nonce
+	p.muMeta.Unlock()
+}
+
+// GetScriptNonce returns the nonce added to every script tag
+func (p *Page) GetScriptNonce() string {
+	p.muMeta.RLock()
+	defer p.muMeta.RUnlock()
+
+	return p.scriptNonce
+}
+
+func newScriptNonce() (string, error) {
+	b := make([]byte, nonceSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// PipelineProcessorScriptNonce adds the Page's nonce to every script tag
+func PipelineProcessorScriptNonce(page *Page) *PipelineProcessor {
+	pp := NewPipelineProcessor(PipelineProcessorKeyScriptNonce)
+
+	pp.AfterTagger = func(ctx context.Context, w io.Writer, tag *Tag) (*Tag, error) {
+		if tag.GetName() != "script" {
+			return tag, nil
+		}
+
+		if nonce := page.GetScriptNonce(); nonce != "" {
+			tag.AddAttributes(Attrs{"nonce": nonce})
+		}
+
+		return tag, nil
+	}
+
+	return pp
+}
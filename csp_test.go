@@ -0,0 +1,63 @@
+package hlive_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	l "github.com/SamHennessy/hlive"
+)
+
+func TestCSP_PageServer(t *testing.T) {
+	t.Parallel()
+
+	s := l.NewPageServer(func() *l.Page {
+		page := l.NewPage()
+		page.DOM().Body().Add(l.T("script", l.HTML("console.log('hi')")))
+
+		return page
+	})
+	s.CSP = l.CSPHeader
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	match := regexp.MustCompile(`'nonce-([^']+)'`).FindStringSubmatch(w.Header().Get("Content-Security-Policy"))
+	if match == nil {
+		t.Fatal("csp header not set")
+	}
+
+	body := w.Body.String()
+	scripts := strings.Count(body, "<script")
+
+	if scripts != 2 || strings.Count(body, `nonce="`+match[1]+`"`) != scripts {
+		t.Error("nonce not on every script:", body)
+	}
+}
+
+func TestCSP_PageOptionScriptNonce(t *testing.T) {
+	t.Parallel()
+
+	script := l.T("script", l.HTML("console.log('hi')"))
+
+	page := l.NewPage(l.PageOptionScriptNonce("abc"))
+	page.DOM().Body().Add(script)
+
+	buf := bytes.NewBuffer(nil)
+	if _, err := page.RunRenderPipeline(context.Background(), buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), `nonce="abc"`) {
+		t.Error("nonce not rendered")
+	}
+
+	// The nonce is only added to the rendered copy
+	if script.GetAttribute("nonce") != nil {
+		t.Error("nonce added to the page's tag")
+	}
+}
@@ -135,16 +135,16 @@ func (d *Differ) Trees(selector, path string, oldNode, newNode any) ([]Diff, err
 				Text: &newStr,
 			})
 		}
-	case HTML:
-		newHTML, _ := newNode.(HTML)
+	case *HTML:
+		newHTML, _ := newNode.(*HTML)
 
 		// content doesn't match, update content
-		if v != newHTML {
+		if *v != *newHTML {
 			diffs = append(diffs, Diff{
 				Root: selector,
 				Path: path,
 				Type: DiffUpdate,
-				HTML: &newHTML,
+				HTML: newHTML,
 			})
 		}
 	case Tagger:
@@ -162,6 +162,11 @@ func (d *Differ) Trees(selector, path string, oldNode, newNode any) ([]Diff, err
 			return diffs, nil
 		}
 
+		// The same memoised subtree, from a memo hit. A different subtree with the same key still needs a diff.
+		if key := memoKey(newTag); key != "" && v == newTag {
+			return diffs, nil
+		}
+
 		// Attributes
 		// The browser doesn't care about the order as we use setAttribute and removeAttribute.
 
@@ -182,6 +187,11 @@ func (d *Differ) Trees(selector, path string, oldNode, newNode any) ([]Diff, err
 
 		// Update existing or create new
 		for i := 0; i < len(newAttrs); i++ {
+			// Only for the server
+			if newAttrs[i].GetName() == AttrMemo {
+				continue
+			}
+
 			oldAttr, exits := oldAttrsMap[newAttrs[i].GetName()]
 
 			if !exits || newAttrs[i].GetValue() != oldAttr.GetValue() {
@@ -202,7 +212,7 @@ func (d *Differ) Trees(selector, path string, oldNode, newNode any) ([]Diff, err
 		// Delete old attrs that have been removed
 		for i := 0; i < len(oldAttrs); i++ {
 			_, exits := newAttrsMap[oldAttrs[i].GetName()]
-			if !exits {
+			if !exits && oldAttrs[i].GetName() != AttrMemo {
 				diffs = append(diffs, Diff{
 					Root:      selector,
 					Path:      path,
@@ -0,0 +1,421 @@
+package hlive
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/SamHennessy/hlive/internal/domapply"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Normalise a DOM for comparing, attribute order doesn't matter to the browser
+func renderDOM(t *testing.T, doc *html.Node) string {
+	t.Helper()
+
+	var sortAttrs func(n *html.Node)
+	sortAttrs = func(n *html.Node) {
+		for i := 1; i < len(n.Attr); i++ {
+			for j := i; j > 0 && n.Attr[j].Key < n.Attr[j-1].Key; j-- {
+				n.Attr[j], n.Attr[j-1] = n.Attr[j-1], n.Attr[j]
+			}
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			sortAttrs(child)
+		}
+	}
+
+	sortAttrs(doc)
+
+	buf := bytes.NewBuffer(nil)
+	if err := html.Render(buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+// Random trees
+//
+// fuzzNode is a plain tree that's converted to hlive nodes, that way it can be copied and changed for the new tree.
+
+const (
+	fuzzKindTag = iota
+	fuzzKindText
+	fuzzKindHTML
+	fuzzKindGroup
+	fuzzKindInt
+	fuzzKindComponent
+	fuzzKinds
+)
+
+var (
+	fuzzTagNames  = []string{"div", "span", "b", "section", "em", "br"}
+	fuzzTexts     = []string{"a", "b c", " ", "<&>", "", "héllo", "\"q\""}
+	fuzzHTMLs     = []string{"<i>x</i>", "<u>y<b>z</b></u>", "<hr>"}
+	fuzzAttrNames = []string{"class", "title", "data-x", "lang"}
+	fuzzAttrVals  = []string{"", "one", "two three", "a&b", "<\">"}
+)
+
+type fuzzNode struct {
+	kind  int
+	name  string
+	text  string
+	attrs [][2]string
+	kids  []*fuzzNode
+	hid   string
+}
+
+// Reads decisions from the fuzz input, zero when it runs out
+type fuzzInput struct {
+	data []byte
+	i    int
+	hid  int
+}
+
+func (in *fuzzInput) next(n int) int {
+	if in.i >= len(in.data) || n <= 0 {
+		return 0
+	}
+
+	v := int(in.data[in.i]) % n
+	in.i++
+
+	return v
+}
+
+func (in *fuzzInput) node(depth int) *fuzzNode {
+	kind := in.next(fuzzKinds)
+	if depth > 3 && (kind == fuzzKindTag || kind == fuzzKindGroup || kind == fuzzKindComponent) {
+		kind = fuzzKindText
+	}
+
+	n := &fuzzNode{kind: kind}
+
+	switch kind {
+	case fuzzKindText:
+		n.text = fuzzTexts[in.next(len(fuzzTexts))]
+	case fuzzKindHTML:
+		n.text = fuzzHTMLs[in.next(len(fuzzHTMLs))]
+	case fuzzKindInt:
+		n.text = strconv.Itoa(in.next(100))
+	case fuzzKindGroup:
+		n.kids = in.nodes(depth + 1)
+	case fuzzKindTag, fuzzKindComponent:
+		n.name = fuzzTagNames[in.next(len(fuzzTagNames))]
+
+		for i := in.next(3); i > 0; i-- {
+			n.setAttr(fuzzAttrNames[in.next(len(fuzzAttrNames))], fuzzAttrVals[in.next(len(fuzzAttrVals))])
+		}
+
+		if kind == fuzzKindComponent {
+			in.hid++
+			n.hid = "c" + strconv.Itoa(in.hid)
+		}
+
+		if n.name != "br" {
+			n.kids = in.nodes(depth + 1)
+		}
+	}
+
+	return n
+}
+
+func (in *fuzzInput) nodes(depth int) []*fuzzNode {
+	var list []*fuzzNode
+
+	for i := in.next(4); i > 0; i-- {
+		list = append(list, in.node(depth))
+	}
+
+	return list
+}
+
+// Change a copy of the tree
+func (in *fuzzInput) mutate(list []*fuzzNode, depth int) []*fuzzNode {
+	var out []*fuzzNode
+
+	for i := 0; i < len(list); i++ {
+		n := list[i].copy()
+
+		switch in.next(10) {
+		// Delete
+		case 1:
+			continue
+		// Insert before
+		case 2:
+			out = append(out, in.node(depth))
+		// Change content
+		case 3:
+			switch n.kind {
+			case fuzzKindText:
+				n.text = fuzzTexts[in.next(len(fuzzTexts))]
+			case fuzzKindHTML:
+				n.text = fuzzHTMLs[in.next(len(fuzzHTMLs))]
+			case fuzzKindInt:
+				n.text = strconv.Itoa(in.next(100))
+			case fuzzKindTag, fuzzKindComponent:
+				n.setAttr(fuzzAttrNames[in.next(len(fuzzAttrNames))], fuzzAttrVals[in.next(len(fuzzAttrVals))])
+			}
+		// Remove attributes
+		case 4:
+			n.attrs = nil
+		// Rename, Components keep their ID
+		case 5:
+			if n.kind == fuzzKindTag || n.kind == fuzzKindComponent {
+				n.name = fuzzTagNames[in.next(len(fuzzTagNames)-1)]
+			}
+		// Replace
+		case 6:
+			n = in.node(depth)
+		}
+
+		if (n.kind == fuzzKindTag || n.kind == fuzzKindComponent) && n.name != "br" || n.kind == fuzzKindGroup {
+			n.kids = in.mutate(n.kids, depth+1)
+		}
+
+		out = append(out, n)
+	}
+
+	// Append
+	if in.next(3) == 0 {
+		out = append(out, in.nodes(depth)...)
+	}
+
+	return out
+}
+
+func (n *fuzzNode) setAttr(name, value string) {
+	for i := 0; i < len(n.attrs); i++ {
+		if n.attrs[i][0] == name {
+			n.attrs[i][1] = value
+
+			return
+		}
+	}
+
+	n.attrs = append(n.attrs, [2]string{name, value})
+}
+
+func (n *fuzzNode) copy() *fuzzNode {
+	c := *n
+	c.attrs = append([][2]string(nil), n.attrs...)
+	c.kids = append([]*fuzzNode(nil), n.kids...)
+
+	return &c
+}
+
+func (n *fuzzNode) hlive() any {
+	switch n.kind {
+	case fuzzKindText:
+		return n.text
+	case fuzzKindHTML:
+		return HTML(n.text)
+	case fuzzKindInt:
+		v, _ := strconv.Atoi(n.text)
+
+		return v
+	case fuzzKindGroup:
+		return fuzzHLive(n.kids)
+	}
+
+	tag := T(n.name)
+	for i := 0; i < len(n.attrs); i++ {
+		tag.Add(Attrs{n.attrs[i][0]: n.attrs[i][1]})
+	}
+
+	if n.hid != "" {
+		tag.Add(Attrs{AttrID: n.hid})
+	}
+
+	tag.Add(fuzzHLive(n.kids))
+
+	return tag
+}
+
+func fuzzHLive(list []*fuzzNode) *NodeGroup {
+	g := G()
+	for i := 0; i < len(list); i++ {
+		g.Add(list[i].hlive())
+	}
+
+	return g
+}
+
+// Render the tree the way a Page does
+func fuzzTree(t *testing.T, body []*fuzzNode) (*NodeGroup, string) {
+	t.Helper()
+
+	pipeline := NewPipeline(PipelineProcessorConvertToString())
+
+	tree, err := pipeline.run(context.Background(), io.Discard,
+		G(HTML5DocType, T("html", T("head"), T("body", fuzzHLive(body)))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := NewRenderer().HTML(buf, tree); err != nil {
+		t.Fatal(err)
+	}
+
+	return tree, buf.String()
+}
+
+var (
+	fuzzDiffer = NewDiffer()
+	fuzzPage   = NewPage()
+)
+
+// old + diffs == new
+func checkDiffer(t *testing.T, data []byte) {
+	t.Helper()
+
+	in := &fuzzInput{data: data}
+	oldBody := in.nodes(0)
+	newBody := in.mutate(oldBody, 0)
+
+	checkDifferBodies(t, in, oldBody, newBody)
+}
+
+func checkDifferTrees(t *testing.T, oldBody, newBody []*fuzzNode) {
+	t.Helper()
+
+	checkDifferBodies(t, &fuzzInput{}, oldBody, newBody)
+}
+
+func checkDifferBodies(t *testing.T, in *fuzzInput, oldBody, newBody []*fuzzNode) {
+	t.Helper()
+
+	oldTree, oldHTML := fuzzTree(t, oldBody)
+	newTree, newHTML := fuzzTree(t, newBody)
+
+	diffs, err := fuzzDiffer.Trees("doc", "", oldTree, newTree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := fuzzPage.diffsToMsg(diffs)
+
+	doc, err := html.Parse(strings.NewReader(oldHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Browser plugins can add nodes above the head
+	extension := in.next(2) == 1
+	if extension {
+		addExtensionNode(doc)
+	}
+
+	if err := domapply.Message(doc, message, domapply.Hooks{}); err != nil {
+		t.Fatalf("apply: %v\nold: %s\nnew: %s\ndiffs:\n%s", err, oldHTML, newHTML, fuzzMessage(message))
+	}
+
+	want, err := html.Parse(strings.NewReader(newHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if extension {
+		addExtensionNode(want)
+	}
+
+	if got, want := renderDOM(t, doc), renderDOM(t, want); got != want {
+		t.Fatalf("DOM mismatch\nold:  %s\nnew:  %s\ngot:  %s\nwant: %s\ndiffs:\n%s",
+			oldHTML, newHTML, got, want, fuzzMessage(message))
+	}
+}
+
+func addExtensionNode(doc *html.Node) {
+	root := doc.LastChild
+
+	root.InsertBefore(&html.Node{
+		Type:     html.ElementNode,
+		Data:     "script",
+		DataAtom: atom.Script,
+		Attr:     []html.Attribute{{Key: "src", Val: "extension.js"}},
+	}, root.FirstChild)
+}
+
+// Decode the diff contents to make failures readable
+func fuzzMessage(message string) string {
+	var sb strings.Builder
+
+	lines := strings.Split(message, "\n")
+	for i := 0; i < len(lines); i++ {
+		parts := strings.Split(lines[i], "|")
+		if len(parts) != 6 {
+			continue
+		}
+
+		b, _ := base64.StdEncoding.DecodeString(parts[5])
+		parts[5] = string(b)
+
+		sb.WriteString(strings.Join(parts, "|") + "\n")
+	}
+
+	return sb.String()
+}
+
+func FuzzDiffer_Trees(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{1, 0, 0})
+	f.Add([]byte{3, 0, 1, 0, 2, 3, 1, 1, 0, 3, 4})
+	f.Add([]byte{2, 3, 2, 1, 0, 1, 2, 3, 3, 0, 2})
+	f.Add([]byte{2, 5, 0, 1, 0, 2, 1, 1, 3, 0, 5, 1, 2, 6, 0})
+	f.Add([]byte{3, 2, 1, 2, 0, 1, 0, 5, 9, 9, 3, 3, 0, 6, 2, 5, 1})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkDiffer(t, data)
+	})
+}
+
+func TestDiffer_TreesRandom(t *testing.T) {
+	t.Parallel()
+
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		data := make([]byte, 10+r.Intn(100))
+		r.Read(data)
+
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			checkDiffer(t, data)
+		})
+	}
+}
+
+func TestDiffer_TreesHTMLUpdate(t *testing.T) {
+	t.Parallel()
+
+	checkDifferTrees(t, []*fuzzNode{{kind: fuzzKindHTML, text: "<i>x</i>"}},
+		[]*fuzzNode{{kind: fuzzKindHTML, text: "<hr>"}})
+}
+
+func TestDiffer_TreesNestedGroupText(t *testing.T) {
+	t.Parallel()
+
+	group := func(text string) []*fuzzNode {
+		return []*fuzzNode{
+			{kind: fuzzKindText, text: "a"},
+			{kind: fuzzKindGroup, kids: []*fuzzNode{{kind: fuzzKindInt, text: text}}},
+		}
+	}
+
+	checkDifferTrees(t, group("1"), group("2"))
+}
+
+func TestDiffer_TreesAttributeNotEscaped(t *testing.T) {
+	t.Parallel()
+
+	checkDifferTrees(t, []*fuzzNode{{kind: fuzzKindTag, name: "div"}},
+		[]*fuzzNode{{kind: fuzzKindTag, name: "div", attrs: [][2]string{{"title", `a&b "c" <d>`}}}})
+}
@@ -0,0 +1,153 @@
+package hlive
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+var ErrBodyNotFound = errors.New("body not found")
+
+// AttrDrift tells page.js how often, in milliseconds, to check the browser DOM for drift
+const AttrDrift = "data-hlive-drift"
+
+// PageOptionDriftCheck makes the browser check its body against the server's view of it at each interval. When they
+// don't match, for example after a browser plugin changed the DOM, the server sends the browser a new body.
+//
+// The browser always asks for a new body when it can't apply a diff.
+func PageOptionDriftCheck(interval time.Duration) func(*Page) {
+	return func(p *Page) {
+		p.dom.html.Add(Attrs{AttrDrift: strconv.FormatInt(interval.Milliseconds(), 10)})
+	}
+}
+
+// A browser's checksum of its body, an empty checksum always resyncs
+func (p *Page) processMsgResync(ctx context.Context, msg websocketMessage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	body := p.browserBody()
+	if body == nil {
+		return
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := p.renderer.HTML(buf, body); err != nil {
+		p.logger.Error("resync: render body", "error", err)
+
+		return
+	}
+
+	checksum, err := bodyChecksum(buf.String())
+	if err != nil {
+		p.logger.Error("resync: checksum", "error", err)
+
+		return
+	}
+
+	if msg.Data["c"] == checksum {
+		return
+	}
+
+	p.logger.Warn("resync: browser DOM has drifted", "browser", msg.Data["c"], "server", checksum)
+
+	p.wsSend(ctx, "y|r|"+base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// The body tag we think the browser has, must hold the lock
+func (p *Page) browserBody() *Tag {
+	tree, ok := p.domBrowser.(*NodeGroup)
+	if !ok || tree == nil {
+		return nil
+	}
+
+	nodes := tree.Get()
+	for i := 0; i < len(nodes); i++ {
+		root, ok := nodes[i].(*Tag)
+		if !ok || root.GetName() != "html" {
+			continue
+		}
+
+		kids := root.GetNodes().Get()
+		for j := 0; j < len(kids); j++ {
+			if body, ok := kids[j].(*Tag); ok && body.GetName() == "body" {
+				return body
+			}
+		}
+	}
+
+	return nil
+}
+
+// Parse the body like a browser would and get the same checksum as hlive.domChecksum
+func bodyChecksum(body string) (string, error) {
+	n, err := parseBody(body)
+	if err != nil {
+		return "", err
+	}
+
+	sb := &strings.Builder{}
+	domSerialize(sb, n)
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(sb.String()))
+
+	return strconv.FormatUint(uint64(h.Sum32()), 16), nil
+}
+
+// Parse the HTML of a body tag, like the browser does
+func parseBody(body string) (*html.Node, error) {
+	doc, err := html.Parse(strings.NewReader("<!doctype html><html><head></head>" + body + "</html>"))
+	if err != nil {
+		return nil, fmt.Errorf("parse body: %w", err)
+	}
+
+	var find func(n *html.Node) *html.Node
+	find = func(n *html.Node) *html.Node {
+		if n.Type == html.ElementNode && n.Data == "body" {
+			return n
+		}
+
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if found := find(child); found != nil {
+				return found
+			}
+		}
+
+		return nil
+	}
+
+	n := find(doc)
+	if n == nil {
+		return nil, ErrBodyNotFound
+	}
+
+	return n, nil
+}
+
+// Tag names and text, like hlive.domSerialize. Attributes are left out as scripts often change them.
+func domSerialize(sb *strings.Builder, n *html.Node) {
+	// The browser keeps template content out of the DOM
+	if n.Type == html.ElementNode && n.Data == "template" {
+		return
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.ElementNode:
+			sb.WriteString("<" + child.Data + ">")
+			domSerialize(sb, child)
+			sb.WriteString("</>")
+		case html.TextNode:
+			sb.WriteString(child.Data)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package hlive_test
+
+import (
+	"encoding/base64"
+	"hash/fnv"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+)
+
+func driftPage() *l.Page {
+	page := l.NewPage(l.PageOptionDriftCheck(5 * time.Second))
+	page.DOM().Body().Add(l.T("p", "Hello"))
+
+	return page
+}
+
+func TestPageOptionDriftCheck(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(l.NewPageServer(driftPage))
+	t.Cleanup(ts.Close)
+
+	res, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), l.AttrDrift+`="5000"`) {
+		t.Fatalf("drift attribute not found: %s", b)
+	}
+}
+
+func TestPage_Resync(t *testing.T) {
+	t.Parallel()
+
+	conn := dialPageServer(t, l.NewPageServer(driftPage))
+
+	waitForMessage(t, conn, "d|c|doc")
+
+	// Same format as hlive.domSerialize
+	h := fnv.New32a()
+	_, _ = h.Write([]byte("<p>Hello</>"))
+	checksum := strconv.FormatUint(uint64(h.Sum32()), 16)
+
+	if err := conn.WriteJSON(map[string]any{"t": "y", "d": map[string]string{"c": checksum}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, msg, err := conn.ReadMessage(); err == nil {
+		t.Fatalf("unexpected message for a matching checksum: %s", msg)
+	}
+
+	conn = dialPageServer(t, l.NewPageServer(driftPage))
+
+	waitForMessage(t, conn, "d|c|doc")
+
+	if err := conn.WriteJSON(map[string]any{"t": "y", "d": map[string]string{"c": "bad"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(string(msg), "y|r|"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(msg), "y|r|") || !strings.Contains(string(body), "<p") ||
+		!strings.Contains(string(body), "Hello") {
+		t.Fatalf("unexpected resync message: %s", body)
+	}
+}
@@ -8,6 +8,8 @@ import (
 type Event struct {
 	// The binding that was listening for this event
 	Binding *EventBinding
+	// Type of the JavaScript event, e.g. "click"
+	Type string
 	// If an input has a value set by the browsers on page load, different to the inputs value attribute this type of
 	// event is sent. This typically happens on page reload after data has been inputted to a field.
 	IsInitial bool
@@ -0,0 +1,74 @@
+package hlive
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Strict event rejections
+var (
+	ErrEventNotRendered  = errors.New("event binding not in the browser's DOM")
+	ErrEventDisabled     = errors.New("event element is disabled")
+	ErrEventTypeMismatch = errors.New("event type doesn't match the binding")
+)
+
+// PageOptionStrictEvents only calls an event handler if its binding is on an element the browser has, the element
+// isn't disabled, and the event type matches the binding. Use Page.HookEventRejectAdd to know about rejected events.
+func PageOptionStrictEvents() func(*Page) {
+	return func(p *Page) {
+		p.strictEvents = true
+	}
+}
+
+// HookEventRejectAdd adds a hook that's called when strict events rejects an event
+func (p *Page) HookEventRejectAdd(hook func(ctx context.Context, e Event, err error)) {
+	p.hookEventReject = append(p.hookEventReject, hook)
+}
+
+// validateEvent checks the event against what we think the browser DOM is
+func (p *Page) validateEvent(e Event) error {
+	if !strings.EqualFold(e.Type, e.Binding.Name) {
+		return ErrEventTypeMismatch
+	}
+
+	if e.Binding.Component == nil {
+		return ErrEventNotRendered
+	}
+
+	tag := p.GetBrowserNodeByID(e.Binding.Component.GetID())
+	if tag == nil {
+		return ErrEventNotRendered
+	}
+
+	// Is the binding still on the element
+	found := false
+	pairs := strings.Split(tag.GetAttributeValue(AttrOn), ",")
+
+	for i := 0; i < len(pairs); i++ {
+		id, name, _ := strings.Cut(pairs[i], "|")
+		if id == e.Binding.ID && strings.EqualFold(name, e.Binding.Name) {
+			found = true
+
+			break
+		}
+	}
+
+	if !found {
+		return ErrEventNotRendered
+	}
+
+	if tag.GetAttribute("disabled") != nil {
+		return ErrEventDisabled
+	}
+
+	return nil
+}
+
+func (p *Page) rejectEvent(ctx context.Context, e Event, err error) {
+	p.logger.Warn("event rejected", "error", err, "id", e.Binding.ID, "type", e.Type)
+
+	for i := 0; i < len(p.hookEventReject); i++ {
+		p.hookEventReject[i](ctx, e, err)
+	}
+}
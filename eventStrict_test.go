@@ -0,0 +1,104 @@
+package hlive_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/gorilla/websocket"
+)
+
+func TestStrictEvents(t *testing.T) {
+	t.Parallel()
+
+	called := make(chan string, 10)
+	rejected := make(chan error, 10)
+	mounted := make(chan struct{})
+
+	admin := l.On("click", func(_ context.Context, _ l.Event) {
+		called <- "admin"
+	})
+	disabled := l.On("click", func(_ context.Context, _ l.Event) {
+		called <- "disabled"
+	})
+
+	adminBox := l.Box[any](l.C("button", admin))
+
+	hide := l.On("click", func(_ context.Context, _ l.Event) {
+		adminBox.Set("")
+		called <- "hide"
+	})
+
+	s := l.NewPageServer(func() *l.Page {
+		page := l.NewPage(l.PageOptionStrictEvents())
+		page.DOM().Body().Add(
+			l.C("button", hide),
+			l.C("button", l.Attrs{"disabled": ""}, disabled),
+			adminBox,
+		)
+		page.HookMountAdd(func(_ context.Context, _ *l.Page) {
+			close(mounted)
+		})
+		page.HookEventRejectAdd(func(_ context.Context, _ l.Event, err error) {
+			rejected <- err
+		})
+
+		return page
+	})
+
+	conn := dialPageServer(t, s)
+
+	select {
+	case <-mounted:
+	case <-time.After(time.Second):
+		t.Fatal("page not mounted")
+	}
+
+	send := func(id, typ string) {
+		t.Helper()
+
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"t":"e","i":"`+id+`","d":{"type":"`+typ+`"}}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitRejected := func(want error) {
+		t.Helper()
+
+		select {
+		case err := <-rejected:
+			if !errors.Is(err, want) {
+				t.Error("expected", want, "got", err)
+			}
+		case name := <-called:
+			t.Error("handler called:", name)
+		case <-time.After(time.Second):
+			t.Fatal("event not rejected")
+		}
+	}
+
+	send(admin.ID, "keyup")
+	waitRejected(l.ErrEventTypeMismatch)
+
+	send(disabled.ID, "click")
+	waitRejected(l.ErrEventDisabled)
+
+	send(hide.ID, "click")
+
+	select {
+	case name := <-called:
+		if name != "hide" {
+			t.Error("unexpected handler:", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+
+	// Wait for the render that removes the admin button
+	waitForMessage(t, conn, "d|d|")
+
+	send(admin.ID, "click")
+	waitRejected(l.ErrEventNotRendered)
+}
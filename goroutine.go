@@ -0,0 +1,66 @@
+package hlive
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+)
+
+// canceler cancels when it's unmounted or torn down, see ComponentMountable
+type canceler interface {
+	addCancel(cancel func()) (remove func())
+}
+
+// Go runs f in a goroutine for as long as comp is mounted.
+//
+// The context passed to f is cancelled when comp is unmounted or torn down, when the Page is closed, or when the
+// returned function is called. Use the context from Mount or an event handler, so f can call RenderComponent.
+// A panic in f is recovered and logged.
+func Go(ctx context.Context, comp Componenter, f func(ctx context.Context)) context.CancelFunc {
+	ctx, cancel := context.WithCancel(ctx)
+
+	remove := func() {}
+
+	if c, ok := comp.(canceler); ok {
+		remove = c.addCancel(cancel)
+	} else if teardowner, ok := comp.(Teardowner); ok {
+		// Can't be removed, so it's kept until the teardown
+		teardowner.AddTeardown(cancel)
+	}
+
+	go func() {
+		defer remove()
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				LoggerDev.Error("go: panic", "panic", r, "name", comp.GetName(), "stack", string(debug.Stack()))
+			}
+		}()
+
+		f(ctx)
+	}()
+
+	return cancel
+}
+
+// Every calls f at each interval and then renders comp. It's stopped like Go.
+func Every(ctx context.Context, comp Componenter, interval time.Duration, f func(ctx context.Context),
+) context.CancelFunc {
+	return Go(ctx, comp, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				f(ctx)
+
+				if ctx.Err() == nil {
+					RenderComponent(ctx, comp)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package hlive_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+)
+
+func waitClosed(t *testing.T, ch <-chan struct{}, msg string) {
+	t.Helper()
+
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal(msg)
+	}
+}
+
+func TestGo_UnmountCancels(t *testing.T) {
+	t.Parallel()
+
+	comp := l.CM("div")
+	stopped := make(chan struct{})
+
+	l.Go(context.Background(), comp, func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	comp.Unmount(context.Background())
+
+	waitClosed(t, stopped, "not cancelled on unmount")
+}
+
+func TestGo_TeardownCancels(t *testing.T) {
+	t.Parallel()
+
+	comp := l.CM("div")
+	stopped := make(chan struct{})
+
+	l.Go(context.Background(), comp, func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	comp.Teardown()
+
+	waitClosed(t, stopped, "not cancelled on teardown")
+}
+
+func TestGo_PageCloseCancels(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
+	l.Go(ctx, l.C("div"), func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	cancel()
+
+	waitClosed(t, stopped, "not cancelled on page close")
+}
+
+func TestGo_RecoversPanic(t *testing.T) {
+	t.Parallel()
+
+	comp := l.CM("div")
+	stopped := make(chan struct{})
+
+	l.Go(context.Background(), comp, func(ctx context.Context) {
+		defer close(stopped)
+
+		panic("boom")
+	})
+
+	waitClosed(t, stopped, "goroutine not run")
+}
+
+func TestEvery_RendersAfterTick(t *testing.T) {
+	t.Parallel()
+
+	comp := l.CM("div")
+	ctx, rendered := renderComponentContext(context.Background())
+
+	var ticks atomic.Int32
+
+	l.Every(ctx, comp, time.Millisecond, func(ctx context.Context) {
+		ticks.Add(1)
+	})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case got := <-rendered:
+			if got != comp {
+				t.Fatal("wrong component rendered")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("not rendered after tick")
+		}
+	}
+
+	comp.Unmount(ctx)
+
+	if ticks.Load() < 2 {
+		t.Errorf("expected at least 2 ticks, got %d", ticks.Load())
+	}
+}
@@ -112,7 +112,7 @@ func IsNode(node any) bool {
 	case nil, string, HTML, *HTML, Tagger,
 		[]any, *NodeGroup, []*Component, []*Tag, []Componenter, []Tagger, []UniqueTagger,
 		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64,
-		NodeBoxer, LockBoxer:
+		NodeBoxer, LockBoxer, ContextNodeBoxer:
 		return true
 	default:
 		return false
@@ -196,6 +196,9 @@ func (g *NodeGroup) Get() []any {
 		}
 
 		switch v := node.(type) {
+		// Resolved by the pipeline
+		case ContextNodeBoxer:
+			newGroup = append(newGroup, node)
 		case NodeBoxer:
 			newGroup = append(newGroup, v.GetNode())
 		case LockBoxer:
@@ -270,6 +273,9 @@ func (g *ElementGroup) Get() []any {
 		}
 
 		switch v := node.(type) {
+		// Resolved by the pipeline
+		case ContextNodeBoxer:
+			newGroup = append(newGroup, node)
 		case NodeBoxer:
 			newGroup = append(newGroup, v.GetNode())
 		case LockBoxer:
@@ -2,6 +2,7 @@ package hlivekit
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/dgraph-io/ristretto/v2"
 
@@ -16,16 +17,14 @@ func NewCacheRistretto(cache *ristretto.Cache[string, any]) *CacheRistretto {
 	return &CacheRistretto{cache: cache}
 }
 
-// CacheRistretto is a Cache adapter for Ristretto (github.com/dgraph-io/ristretto/v2).
+// CacheRistretto is a CacheAdvanced adapter for Ristretto (github.com/dgraph-io/ristretto/v2).
 type CacheRistretto struct {
 	cache *ristretto.Cache[string, any]
 }
 
 func (c *CacheRistretto) Get(key any) (any, bool) {
-	k, ok := key.(string)
+	k, ok := cacheKey("CacheRistretto", key)
 	if !ok {
-		l.LoggerDev.Error("CacheRistretto: key is not a string", "callers", l.CallerStackStr(), "key", fmt.Sprintf("%#v", key))
-
 		return nil, false
 	}
 
@@ -33,12 +32,42 @@ func (c *CacheRistretto) Get(key any) (any, bool) {
 }
 
 func (c *CacheRistretto) Set(key any, value any) {
-	k, ok := key.(string)
+	c.SetWithTTL(key, value, cacheCost(value), 0)
+}
+
+func (c *CacheRistretto) SetWithTTL(key any, value any, cost int64, ttl time.Duration) {
+	k, ok := cacheKey("CacheRistretto", key)
 	if !ok {
-		l.LoggerDev.Error("CacheRistretto: key is not a string", "callers", l.CallerStackStr(), "key", fmt.Sprintf("%#v", key))
+		return
+	}
 
+	c.cache.SetWithTTL(k, value, cost, ttl)
+}
+
+func (c *CacheRistretto) Delete(key any) {
+	k, ok := cacheKey("CacheRistretto", key)
+	if !ok {
 		return
 	}
 
-	c.cache.Set(k, value, 0)
+	c.cache.Del(k)
+}
+
+// HLive always uses string keys
+func cacheKey(name string, key any) (string, bool) {
+	k, ok := key.(string)
+	if !ok {
+		l.LoggerDev.Error(name+": key is not a string", "callers", l.CallerStackStr(), "key", fmt.Sprintf("%#v", key))
+	}
+
+	return k, ok
+}
+
+// The size of the value in bytes, zero lets the cache work it out
+func cacheCost(value any) int64 {
+	if b, ok := value.([]byte); ok {
+		return int64(len(b))
+	}
+
+	return 0
 }
@@ -0,0 +1,185 @@
+package hlivekit
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+)
+
+// The expiry time at the start of each file
+const cacheFSHeaderSize = 8
+
+// NewCacheFS creates a cache that keeps each value in a file in dir, so processes on the same host can share it.
+//
+// Values must be []byte, which is what HLive caches. Expired values are removed when read, call Prune from time to
+// time to remove the rest.
+func NewCacheFS(dir string) (*CacheFS, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create dir: %w", err)
+	}
+
+	return &CacheFS{dir: dir}, nil
+}
+
+// CacheFS is a CacheAdvanced backed by the filesystem
+type CacheFS struct {
+	dir string
+}
+
+func (c *CacheFS) Get(key any) (any, bool) {
+	path, ok := c.path(key)
+	if !ok {
+		return nil, false
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			l.Logger.Error("CacheFS: read", "error", err)
+		}
+
+		return nil, false
+	}
+
+	if len(b) < cacheFSHeaderSize {
+		return nil, false
+	}
+
+	if cacheFSExpired(b) {
+		_ = os.Remove(path)
+
+		return nil, false
+	}
+
+	return b[cacheFSHeaderSize:], true
+}
+
+func (c *CacheFS) Set(key any, value any) {
+	c.SetWithTTL(key, value, 0, 0)
+}
+
+// SetWithTTL writes the value to a temp file first, so other processes never read part of a value. Cost isn't used.
+func (c *CacheFS) SetWithTTL(key any, value any, _ int64, ttl time.Duration) {
+	path, ok := c.path(key)
+	if !ok {
+		return
+	}
+
+	b, ok := value.([]byte)
+	if !ok {
+		l.LoggerDev.Error("CacheFS: value is not a []byte", "callers", l.CallerStackStr(),
+			"value", fmt.Sprintf("%T", value))
+
+		return
+	}
+
+	header := make([]byte, cacheFSHeaderSize)
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(header, uint64(time.Now().Add(ttl).UnixNano()))
+	}
+
+	if err := c.write(path, header, b); err != nil {
+		l.Logger.Error("CacheFS: write", "error", err)
+	}
+}
+
+func (c *CacheFS) Delete(key any) {
+	path, ok := c.path(key)
+	if !ok {
+		return
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		l.Logger.Error("CacheFS: delete", "error", err)
+	}
+}
+
+// Prune removes expired values, and temp files left by a process that stopped while writing
+func (c *CacheFS) Prune() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("read dir: %w", err)
+	}
+
+	for i := 0; i < len(entries); i++ {
+		if entries[i].IsDir() {
+			continue
+		}
+
+		path := filepath.Join(c.dir, entries[i].Name())
+
+		if strings.HasPrefix(entries[i].Name(), ".tmp-") {
+			if info, err := entries[i].Info(); err == nil && time.Since(info.ModTime()) > time.Minute {
+				_ = os.Remove(path)
+			}
+
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+
+		header := make([]byte, cacheFSHeaderSize)
+		_, err = io.ReadFull(f, header)
+		_ = f.Close()
+
+		if err == nil && cacheFSExpired(header) {
+			_ = os.Remove(path)
+		}
+	}
+
+	return nil
+}
+
+// Keys are hashed so any string is a safe file name
+func (c *CacheFS) path(key any) (string, bool) {
+	k, ok := cacheKey("CacheFS", key)
+	if !ok {
+		return "", false
+	}
+
+	return filepath.Join(c.dir, fmt.Sprintf("%x", sha256.Sum256([]byte(k)))), true
+}
+
+func (c *CacheFS) write(path string, header, value []byte) error {
+	f, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp: %w", err)
+	}
+
+	_, err = f.Write(append(header, value...))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("write temp: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		_ = os.Remove(f.Name())
+
+		return fmt.Errorf("rename: %w", err)
+	}
+
+	return nil
+}
+
+func cacheFSExpired(header []byte) bool {
+	expires := int64(binary.BigEndian.Uint64(header[:cacheFSHeaderSize]))
+
+	return expires != 0 && time.Now().UnixNano() > expires
+}
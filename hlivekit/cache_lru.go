@@ -0,0 +1,126 @@
+package hlivekit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// NewCacheLRU creates an in-process cache that removes the least recently used values once their total cost is over
+// maxCost. HLive caches []byte values and gives their length as the cost, other values without a cost cost 1. A maxCost
+// of 0 or less has no limit.
+func NewCacheLRU(maxCost int64) *CacheLRU {
+	return &CacheLRU{
+		maxCost: maxCost,
+		items:   map[any]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+// CacheLRU is a CacheAdvanced with a maximum cost
+type CacheLRU struct {
+	mu      sync.Mutex
+	maxCost int64
+	cost    int64
+	items   map[any]*list.Element
+	// Most recently used at the front
+	order *list.List
+}
+
+type cacheLRUItem struct {
+	key     any
+	value   any
+	cost    int64
+	expires time.Time
+}
+
+func (c *CacheLRU) Get(key any) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	item, _ := el.Value.(*cacheLRUItem)
+	if !item.expires.IsZero() && time.Now().After(item.expires) {
+		c.remove(el)
+
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+
+	return item.value, true
+}
+
+func (c *CacheLRU) Set(key any, value any) {
+	c.SetWithTTL(key, value, 0, 0)
+}
+
+func (c *CacheLRU) SetWithTTL(key any, value any, cost int64, ttl time.Duration) {
+	if cost <= 0 {
+		cost = cacheCost(value)
+	}
+
+	if cost <= 0 {
+		cost = 1
+	}
+
+	item := &cacheLRUItem{key: key, value: value, cost: cost}
+	if ttl > 0 {
+		item.expires = time.Now().Add(ttl)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+
+	// Would remove everything else and still not fit
+	if c.maxCost > 0 && cost > c.maxCost {
+		return
+	}
+
+	c.items[key] = c.order.PushFront(item)
+	c.cost += cost
+
+	for c.maxCost > 0 && c.cost > c.maxCost {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *CacheLRU) Delete(key any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len is the number of values in the cache, including expired values not yet removed
+func (c *CacheLRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.items)
+}
+
+// Cost is the total cost of the values in the cache
+func (c *CacheLRU) Cost() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.cost
+}
+
+// Must hold the lock
+func (c *CacheLRU) remove(el *list.Element) {
+	item, _ := c.order.Remove(el).(*cacheLRUItem)
+	delete(c.items, item.key)
+	c.cost -= item.cost
+}
@@ -1,31 +1,33 @@
 package hlivekit
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/maypok86/otter/v2"
-
-	l "github.com/SamHennessy/hlive"
 )
 
 // NewCacheOtter wraps an Otter cache for use as an HLive Cache.
 //
 // HLive always uses string keys (a page's content hash), so the cache should
 // be created with a string key type, e.g. otter.Must(&otter.Options[string, any]{...}).
+//
+// Otter works out cost with its Weigher option, and a TTL needs its ExpiryCalculator option. A value set without a TTL
+// doesn't expire, even if the ExpiryCalculator gives it one.
 func NewCacheOtter(cache *otter.Cache[string, any]) *CacheOtter {
 	return &CacheOtter{cache: cache}
 }
 
-// CacheOtter is a Cache adapter for Otter (github.com/maypok86/otter/v2).
+// Otter has no way to remove an expiry, so this is used instead. It's short enough to not overflow the clock.
+const cacheOtterNoExpiry = 100 * 365 * 24 * time.Hour
+
+// CacheOtter is a CacheAdvanced adapter for Otter (github.com/maypok86/otter/v2).
 type CacheOtter struct {
 	cache *otter.Cache[string, any]
 }
 
 func (c *CacheOtter) Get(key any) (any, bool) {
-	k, ok := key.(string)
+	k, ok := cacheKey("CacheOtter", key)
 	if !ok {
-		l.LoggerDev.Error("CacheOtter: key is not a string", "callers", l.CallerStackStr(), "key", fmt.Sprintf("%#v", key))
-
 		return nil, false
 	}
 
@@ -33,12 +35,30 @@ func (c *CacheOtter) Get(key any) (any, bool) {
 }
 
 func (c *CacheOtter) Set(key any, value any) {
-	k, ok := key.(string)
-	if !ok {
-		l.LoggerDev.Error("CacheOtter: key is not a string", "callers", l.CallerStackStr(), "key", fmt.Sprintf("%#v", key))
+	c.SetWithTTL(key, value, 0, 0)
+}
 
+func (c *CacheOtter) SetWithTTL(key any, value any, _ int64, ttl time.Duration) {
+	k, ok := cacheKey("CacheOtter", key)
+	if !ok {
 		return
 	}
 
 	c.cache.Set(k, value)
+
+	if ttl <= 0 {
+		// Clear any expiry from an earlier set
+		ttl = cacheOtterNoExpiry
+	}
+
+	c.cache.SetExpiresAfter(k, ttl)
+}
+
+func (c *CacheOtter) Delete(key any) {
+	k, ok := cacheKey("CacheOtter", key)
+	if !ok {
+		return
+	}
+
+	c.cache.Invalidate(k)
 }
@@ -0,0 +1,163 @@
+package hlivekit_test
+
+import (
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/SamHennessy/hlive/hlivekit"
+	"github.com/go-test/deep"
+	"github.com/maypok86/otter/v2"
+)
+
+var (
+	_ l.CacheAdvanced = &hlivekit.CacheLRU{}
+	_ l.CacheAdvanced = &hlivekit.CacheFS{}
+	_ l.CacheAdvanced = &hlivekit.CacheRistretto{}
+	_ l.CacheAdvanced = &hlivekit.CacheOtter{}
+	_ l.CacheAdvanced = &hlivekit.CacheTheine{}
+)
+
+func TestCacheLRU_Evicts(t *testing.T) {
+	t.Parallel()
+
+	c := hlivekit.NewCacheLRU(10)
+
+	c.Set("a", []byte("1234"))
+	c.Set("b", []byte("1234"))
+
+	// a is now the most recent
+	if _, hit := c.Get("a"); !hit {
+		t.Fatal("expected a")
+	}
+
+	c.Set("c", []byte("1234"))
+
+	if _, hit := c.Get("b"); hit {
+		t.Error("expected b to be evicted")
+	}
+
+	if diff := deep.Equal(int64(8), c.Cost()); diff != nil {
+		t.Error(diff)
+	}
+
+	c.Delete("a")
+
+	if diff := deep.Equal(1, c.Len()); diff != nil {
+		t.Error(diff)
+	}
+
+	// Too big to ever fit
+	c.SetWithTTL("d", []byte("x"), 11, 0)
+
+	if _, hit := c.Get("d"); hit {
+		t.Error("expected d to be too big")
+	}
+}
+
+func TestCacheLRU_TTL(t *testing.T) {
+	t.Parallel()
+
+	c := hlivekit.NewCacheLRU(10)
+
+	c.SetWithTTL("a", "value", 1, time.Millisecond)
+	c.SetWithTTL("b", "value", 1, time.Hour)
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, hit := c.Get("a"); hit {
+		t.Error("expected a to expire")
+	}
+
+	if _, hit := c.Get("b"); !hit {
+		t.Error("expected b")
+	}
+}
+
+func TestCacheLRU_NoLimit(t *testing.T) {
+	t.Parallel()
+
+	c := hlivekit.NewCacheLRU(0)
+
+	c.Set("a", []byte("1234"))
+	c.SetWithTTL("b", "value", 100, 0)
+
+	if _, hit := c.Get("a"); !hit {
+		t.Error("expected a")
+	}
+
+	if _, hit := c.Get("b"); !hit {
+		t.Error("expected b")
+	}
+
+	if diff := deep.Equal(int64(104), c.Cost()); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestCacheOtter_ClearTTL(t *testing.T) {
+	t.Parallel()
+
+	c := hlivekit.NewCacheOtter(otter.Must(&otter.Options[string, any]{
+		ExpiryCalculator: otter.ExpiryCreating[string, any](time.Hour),
+	}))
+
+	c.SetWithTTL("a", "v1", 0, time.Millisecond)
+	c.Set("a", "v2")
+
+	time.Sleep(5 * time.Millisecond)
+
+	v, hit := c.Get("a")
+	if !hit {
+		t.Fatal("expected a to not expire")
+	}
+
+	if diff := deep.Equal("v2", v); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestCacheFS(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	c, err := hlivekit.NewCacheFS(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Set("a", []byte("value"))
+	c.SetWithTTL("b", []byte("value"), 0, time.Millisecond)
+
+	// Another process
+	other, err := hlivekit.NewCacheFS(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, hit := other.Get("a")
+	if !hit {
+		t.Fatal("expected a")
+	}
+
+	if diff := deep.Equal([]byte("value"), val); diff != nil {
+		t.Error(diff)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	if err := c.Prune(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, hit := other.Get("b"); hit {
+		t.Error("expected b to expire")
+	}
+
+	other.Delete("a")
+
+	if _, hit := c.Get("a"); hit {
+		t.Error("expected a to be deleted")
+	}
+}
@@ -1,11 +1,9 @@
 package hlivekit
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/Yiling-J/theine-go"
-
-	l "github.com/SamHennessy/hlive"
 )
 
 // NewCacheTheine wraps a Theine cache for use as an HLive Cache.
@@ -16,16 +14,14 @@ func NewCacheTheine(cache *theine.Cache[string, any]) *CacheTheine {
 	return &CacheTheine{cache: cache}
 }
 
-// CacheTheine is a Cache adapter for Theine (github.com/Yiling-J/theine-go).
+// CacheTheine is a CacheAdvanced adapter for Theine (github.com/Yiling-J/theine-go).
 type CacheTheine struct {
 	cache *theine.Cache[string, any]
 }
 
 func (c *CacheTheine) Get(key any) (any, bool) {
-	k, ok := key.(string)
+	k, ok := cacheKey("CacheTheine", key)
 	if !ok {
-		l.LoggerDev.Error("CacheTheine: key is not a string", "callers", l.CallerStackStr(), "key", fmt.Sprintf("%#v", key))
-
 		return nil, false
 	}
 
@@ -33,12 +29,23 @@ func (c *CacheTheine) Get(key any) (any, bool) {
 }
 
 func (c *CacheTheine) Set(key any, value any) {
-	k, ok := key.(string)
+	c.SetWithTTL(key, value, cacheCost(value), 0)
+}
+
+func (c *CacheTheine) SetWithTTL(key any, value any, cost int64, ttl time.Duration) {
+	k, ok := cacheKey("CacheTheine", key)
 	if !ok {
-		l.LoggerDev.Error("CacheTheine: key is not a string", "callers", l.CallerStackStr(), "key", fmt.Sprintf("%#v", key))
+		return
+	}
+
+	c.cache.SetWithTTL(k, value, cost, ttl)
+}
 
+func (c *CacheTheine) Delete(key any) {
+	k, ok := cacheKey("CacheTheine", key)
+	if !ok {
 		return
 	}
 
-	c.cache.Set(k, value, 0)
+	c.cache.Delete(k)
 }
@@ -11,6 +11,7 @@ function diffApply() {
             hlive.sendMsg({
                 t: "e",
                 i: ids["diffapply"][i],
+                d: {type: "diffapply"},
             });
         }
     });
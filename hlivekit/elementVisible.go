@@ -56,10 +56,10 @@ func (a *ElementVisibleAttribute) Initialize(page *l.Page) {
 		return
 	}
 
-	page.DOM().Head().Add(l.T("script", l.HTML(ElementVisibleJavaScript)))
+	page.ScriptAdd(ElementVisibleJavaScript)
 }
 
 func (a *ElementVisibleAttribute) InitializeSSR(page *l.Page) {
 	a.rendered = true
-	page.DOM().Head().Add(l.T("script", l.HTML(ElementVisibleJavaScript)))
+	page.ScriptAdd(ElementVisibleJavaScript)
 }
@@ -22,6 +22,7 @@ if (hlive.afterMessage.get("hElementVisible") === undefined) {
                     hlive.sendMsg({
                         t: "e",
                         i: ids["helementvisible"][i],
+                        d: {type: "helementvisible"},
                         e: {
                             "isIntersecting": entity.isIntersecting.toString(),
                             "intersectionRatio": entity.intersectionRatio.toString()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"sync"
+	"time"
 
 	"github.com/SamHennessy/hlive"
 	"github.com/teris-io/shortid"
@@ -12,6 +13,10 @@ import (
 type QueueMessage struct {
 	Topic string
 	Value any
+	// Wildcards are the parts of Topic matched by the wildcards in the subscribed topic, in order
+	Wildcards []string
+	// ReplyTo is the topic for the reply to a Request
+	ReplyTo string
 }
 
 type QueueSubscriber interface {
@@ -34,14 +39,55 @@ type PubSubSSRMounter interface {
 	PubSubSSRMount(context.Context, *PubSub)
 }
 
+// PubSub sends published messages to subscribers.
+//
+// Topics are made of tokens separated by dots, for example "orders.42.created". A subscription can use wildcards:
+// "*" matches one token, "orders.*.created", and ">" at the end matches one or more tokens, "orders.>".
+// You can't publish to a topic with wildcards.
 type PubSub struct {
 	mu          sync.RWMutex
 	subscribers map[string][]QueueSubscriber
+	// Subscribed topics with wildcards
+	patterns map[string]struct{}
+	backend  PubSubBackend
+	// Stop getting messages from the backend
+	backendClose func()
+	// The last messages of each topic, for replays
+	retain           int
+	retainTopics     int
+	retainSeq        uint64
+	retainedMessages map[string][]retainedMessage
+	muRetain         sync.Mutex
+	// Used when a Request's context has no deadline
+	requestTimeout time.Duration
+}
+
+type PubSubOption func(*PubSub)
+
+func NewPubSub(options ...PubSubOption) *PubSub {
+	ps := &PubSub{
+		subscribers: map[string][]QueueSubscriber{},
+		patterns:    map[string]struct{}{},
+
+		retainTopics:     defaultRetainTopics,
+		retainedMessages: map[string][]retainedMessage{},
+	}
+
+	for i := 0; i < len(options); i++ {
+		options[i](ps)
+	}
+
+	if ps.backend != nil {
+		ps.backendClose = ps.backend.Subscribe(ps.publishLocal)
+	}
+
+	return ps
 }
 
-func NewPubSub() *PubSub {
-	return &PubSub{
-		subscribers: map[string][]QueueSubscriber{},
+// Close stops getting messages from the backend
+func (ps *PubSub) Close() {
+	if ps.backendClose != nil {
+		ps.backendClose()
 	}
 }
 
@@ -59,14 +105,7 @@ func (ps *PubSub) Subscribe(sub QueueSubscriber, topics ...string) {
 	}
 
 	// A Publish can trigger a Subscribe. Subscribe will be added after the Publish
-	go func() {
-		ps.mu.Lock()
-		defer ps.mu.Unlock()
-
-		for i := 0; i < len(topics); i++ {
-			ps.subscribers[topics[i]] = append(ps.subscribers[topics[i]], sub)
-		}
-	}()
+	go ps.subscribe(sub, topics)
 }
 
 func (ps *PubSub) SubscribeWait(sub QueueSubscriber, topics ...string) {
@@ -82,11 +121,24 @@ func (ps *PubSub) SubscribeWait(sub QueueSubscriber, topics ...string) {
 		return
 	}
 
+	ps.subscribe(sub, topics)
+}
+
+func (ps *PubSub) subscribe(sub QueueSubscriber, topics []string) {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
 
+	ps.subscribeLocked(sub, topics)
+}
+
+// Must hold the lock
+func (ps *PubSub) subscribeLocked(sub QueueSubscriber, topics []string) {
 	for i := 0; i < len(topics); i++ {
 		ps.subscribers[topics[i]] = append(ps.subscribers[topics[i]], sub)
+
+		if topicHasWildcard(topics[i]) {
+			ps.patterns[topics[i]] = struct{}{}
+		}
 	}
 }
 
@@ -117,6 +169,24 @@ func (ps *PubSub) UnsubscribeWait(sub QueueSubscriber, topics ...string) {
 		return
 	}
 
+	ps.unsubscribe(sub, topics)
+}
+
+func (ps *PubSub) Unsubscribe(sub QueueSubscriber, topics ...string) {
+	if len(topics) == 0 {
+		hlive.LoggerDev.Warn("no topics passed", "callers", hlive.CallerStackStr())
+	}
+
+	if sub == nil {
+		hlive.LoggerDev.Warn("sub when nil", "callers", hlive.CallerStackStr())
+
+		return
+	}
+
+	go ps.unsubscribe(sub, topics)
+}
+
+func (ps *PubSub) unsubscribe(sub QueueSubscriber, topics []string) {
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
 
@@ -131,49 +201,79 @@ func (ps *PubSub) UnsubscribeWait(sub QueueSubscriber, topics ...string) {
 			newList = append(newList, ps.subscribers[topics[i]][j])
 		}
 
+		if len(newList) == 0 {
+			delete(ps.subscribers, topics[i])
+			delete(ps.patterns, topics[i])
+
+			continue
+		}
+
 		ps.subscribers[topics[i]] = newList
 	}
 }
 
-func (ps *PubSub) Unsubscribe(sub QueueSubscriber, topics ...string) {
-	if len(topics) == 0 {
-		hlive.LoggerDev.Warn("no topics passed", "callers", hlive.CallerStackStr())
-	}
-
-	if sub == nil {
-		hlive.LoggerDev.Warn("sub when nil", "callers", hlive.CallerStackStr())
+// Publish sends the message to the backend, if there is one, which sends it back to each PubSub.
+// The topic can't have wildcards.
+func (ps *PubSub) Publish(topic string, value any) {
+	if topicHasWildcard(topic) {
+		hlive.LoggerDev.Warn("can't publish to a topic with wildcards", "callers", hlive.CallerStackStr(),
+			"topic", topic)
 
 		return
 	}
 
-	go func() {
-		ps.mu.Lock()
-		defer ps.mu.Unlock()
+	ps.publish(QueueMessage{Topic: topic, Value: value})
+}
 
-		for i := 0; i < len(topics); i++ {
-			var newList []QueueSubscriber
+func (ps *PubSub) publish(item QueueMessage) {
+	if ps.backend == nil {
+		ps.publishLocal(item)
 
-			for j := 0; j < len(ps.subscribers[topics[i]]); j++ {
-				if ps.subscribers[topics[i]][j].GetID() == sub.GetID() {
-					continue
-				}
+		return
+	}
 
-				newList = append(newList, ps.subscribers[topics[i]][j])
-			}
+	if err := ps.backend.Publish(item); err != nil {
+		hlive.Logger.Error("pubsub: backend publish", "error", err, "topic", item.Topic)
+	}
+}
 
-			ps.subscribers[topics[i]] = newList
-		}
-	}()
+type delivery struct {
+	sub     QueueSubscriber
+	message QueueMessage
 }
 
-func (ps *PubSub) Publish(topic string, value any) {
+func (ps *PubSub) publishLocal(item QueueMessage) {
 	// Multiple Publish calls can run concurrently
 	ps.mu.RLock()
-	defer ps.mu.RUnlock()
 
-	item := QueueMessage{topic, value}
-	for i := 0; i < len(ps.subscribers[topic]); i++ {
-		ps.subscribers[topic][i].OnMessage(item)
+	// With the same lock as the subscribers are found, so a subscriber gets it from the replay or from here, not both
+	ps.retainMessage(item)
+
+	var deliveries []delivery
+
+	for i := 0; i < len(ps.subscribers[item.Topic]); i++ {
+		deliveries = append(deliveries, delivery{sub: ps.subscribers[item.Topic][i], message: item})
+	}
+
+	for pattern := range ps.patterns {
+		wildcards, ok := topicMatch(pattern, item.Topic)
+		if !ok {
+			continue
+		}
+
+		msg := item
+		msg.Wildcards = wildcards
+
+		for i := 0; i < len(ps.subscribers[pattern]); i++ {
+			deliveries = append(deliveries, delivery{sub: ps.subscribers[pattern][i], message: msg})
+		}
+	}
+
+	ps.mu.RUnlock()
+
+	// Not holding the lock, a subscriber can wait or subscribe
+	for i := 0; i < len(deliveries); i++ {
+		deliveries[i].sub.OnMessage(deliveries[i].message)
 	}
 }
 
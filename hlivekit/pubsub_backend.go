@@ -0,0 +1,82 @@
+package hlivekit
+
+import (
+	"sync"
+
+	"github.com/teris-io/shortid"
+)
+
+// PubSubBackend carries published messages between PubSubs, for example on different servers.
+// Subscribers stay local to each PubSub, a backend only fans messages out.
+type PubSubBackend interface {
+	// Publish sends the message to every PubSub using the backend, including the one that published it
+	Publish(message QueueMessage) error
+	// Subscribe calls deliver for each message published on the backend. Call the returned func to stop.
+	Subscribe(deliver func(message QueueMessage)) (unsubscribe func())
+}
+
+// PubSubOptionBackend sends all messages through backend
+func PubSubOptionBackend(backend PubSubBackend) PubSubOption {
+	return func(ps *PubSub) {
+		ps.backend = backend
+	}
+}
+
+// NewPubSubBackendMemory creates a backend that connects PubSubs in the same process.
+// Values are passed as they are.
+func NewPubSubBackendMemory() *PubSubBackendMemory {
+	return &PubSubBackendMemory{}
+}
+
+// PubSubBackendMemory is an in-process PubSubBackend
+type PubSubBackendMemory struct {
+	delivers pubSubDelivers
+}
+
+func (b *PubSubBackendMemory) Publish(message QueueMessage) error {
+	b.delivers.deliver(message)
+
+	return nil
+}
+
+func (b *PubSubBackendMemory) Subscribe(deliver func(message QueueMessage)) func() {
+	return b.delivers.add(deliver)
+}
+
+// The PubSubs subscribed to a backend
+type pubSubDelivers struct {
+	mu       sync.RWMutex
+	delivers map[string]func(message QueueMessage)
+}
+
+func (d *pubSubDelivers) add(deliver func(message QueueMessage)) func() {
+	id := shortid.MustGenerate()
+
+	d.mu.Lock()
+	if d.delivers == nil {
+		d.delivers = map[string]func(message QueueMessage){}
+	}
+
+	d.delivers[id] = deliver
+	d.mu.Unlock()
+
+	return func() {
+		d.mu.Lock()
+		delete(d.delivers, id)
+		d.mu.Unlock()
+	}
+}
+
+func (d *pubSubDelivers) deliver(message QueueMessage) {
+	d.mu.RLock()
+	delivers := make([]func(message QueueMessage), 0, len(d.delivers))
+	for _, deliver := range d.delivers {
+		delivers = append(delivers, deliver)
+	}
+	d.mu.RUnlock()
+
+	// A PubSub can be created, or closed, while we deliver
+	for i := 0; i < len(delivers); i++ {
+		delivers[i](message)
+	}
+}
@@ -0,0 +1,44 @@
+package hlivekit_test
+
+import (
+	"testing"
+
+	"github.com/SamHennessy/hlive/hlivekit"
+	"github.com/go-test/deep"
+)
+
+func TestPubSubBackendMemory(t *testing.T) {
+	t.Parallel()
+
+	backend := hlivekit.NewPubSubBackendMemory()
+
+	psA := hlivekit.NewPubSub(hlivekit.PubSubOptionBackend(backend))
+	psB := hlivekit.NewPubSub(hlivekit.PubSubOptionBackend(backend))
+
+	subA := newSub()
+	subB := newSub()
+
+	psA.SubscribeWait(subA, "topic_1")
+	psB.SubscribeWait(subB, "topic_1")
+
+	psA.Publish("topic_1", "foo")
+
+	if !subA.called || !subB.called {
+		t.Fatal("expected both subs to be called")
+	}
+
+	if diff := deep.Equal("foo", subB.calledValue); diff != nil {
+		t.Error(diff)
+	}
+
+	psB.Close()
+
+	subA.wait.Add(1)
+	subB.called = false
+
+	psA.Publish("topic_1", "bar")
+
+	if subB.called {
+		t.Error("unexpected sub call after close")
+	}
+}
@@ -0,0 +1,130 @@
+package hlivekit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/SamHennessy/hlive"
+)
+
+// ErrPrefixWildcard is returned when a NATS subject prefix has a wildcard
+var ErrPrefixWildcard = errors.New("pubsub nats prefix has wildcards")
+
+// PubSubCodec turns values into bytes, and back, for backends that send them to other processes
+type PubSubCodec interface {
+	Encode(topic string, value any) ([]byte, error)
+	Decode(topic string, data []byte) (any, error)
+}
+
+// PubSubCodecJSON is the default PubSubCodec. Decoded values are the types json.Unmarshal uses for any, for example
+// a struct becomes a map[string]any.
+type PubSubCodecJSON struct{}
+
+func (PubSubCodecJSON) Encode(_ string, value any) ([]byte, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal: %w", err)
+	}
+
+	return b, nil
+}
+
+func (PubSubCodecJSON) Decode(_ string, data []byte) (any, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("json unmarshal: %w", err)
+	}
+
+	return value, nil
+}
+
+// NewPubSubBackendNATS creates a backend that sends messages through a NATS server (github.com/nats-io/nats.go).
+//
+// Topics are NATS subjects under prefix, for example "hlive" and "chat.room" is "hlive.chat.room". A "." is added to
+// the end of prefix if it's missing, an empty prefix uses the topic as the subject. A nil codec uses PubSubCodecJSON.
+// All PubSubs using the same server and prefix, in any process, get each other's messages.
+func NewPubSubBackendNATS(conn *nats.Conn, prefix string, codec PubSubCodec) (*PubSubBackendNATS, error) {
+	if strings.ContainsAny(prefix, "*>") {
+		return nil, fmt.Errorf("%w: %s", ErrPrefixWildcard, prefix)
+	}
+
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+
+	if codec == nil {
+		codec = PubSubCodecJSON{}
+	}
+
+	b := &PubSubBackendNATS{
+		conn:   conn,
+		prefix: prefix,
+		codec:  codec,
+	}
+
+	sub, err := conn.Subscribe(prefix+">", b.onMsg)
+	if err != nil {
+		return nil, fmt.Errorf("nats subscribe: %w", err)
+	}
+
+	b.sub = sub
+
+	return b, nil
+}
+
+// PubSubBackendNATS is a PubSubBackend for a NATS server
+type PubSubBackendNATS struct {
+	conn     *nats.Conn
+	sub      *nats.Subscription
+	prefix   string
+	codec    PubSubCodec
+	delivers pubSubDelivers
+}
+
+func (b *PubSubBackendNATS) Publish(message QueueMessage) error {
+	data, err := b.codec.Encode(message.Topic, message.Value)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	msg := &nats.Msg{Subject: b.prefix + message.Topic, Data: data}
+	if message.ReplyTo != "" {
+		msg.Reply = b.prefix + message.ReplyTo
+	}
+
+	if err := b.conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("nats publish: %w", err)
+	}
+
+	return nil
+}
+
+func (b *PubSubBackendNATS) Subscribe(deliver func(message QueueMessage)) func() {
+	return b.delivers.add(deliver)
+}
+
+// Close stops getting messages from the server, it doesn't close the connection
+func (b *PubSubBackendNATS) Close() error {
+	if err := b.sub.Unsubscribe(); err != nil {
+		return fmt.Errorf("nats unsubscribe: %w", err)
+	}
+
+	return nil
+}
+
+func (b *PubSubBackendNATS) onMsg(msg *nats.Msg) {
+	topic := strings.TrimPrefix(msg.Subject, b.prefix)
+
+	value, err := b.codec.Decode(topic, msg.Data)
+	if err != nil {
+		hlive.Logger.Error("pubsub nats: decode", "error", err, "topic", topic)
+
+		return
+	}
+
+	b.delivers.deliver(QueueMessage{Topic: topic, Value: value, ReplyTo: strings.TrimPrefix(msg.Reply, b.prefix)})
+}
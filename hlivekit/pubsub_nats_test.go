@@ -0,0 +1,149 @@
+package hlivekit_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SamHennessy/hlive/hlivekit"
+	"github.com/go-test/deep"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// An embedded server, no network access needed
+func newNATSServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoSigs: true, NoLog: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go s.Start()
+
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+
+	t.Cleanup(s.Shutdown)
+
+	return s
+}
+
+func newNATSPubSub(t *testing.T, s *server.Server) *hlivekit.PubSub {
+	t.Helper()
+
+	conn, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(conn.Close)
+
+	backend, err := hlivekit.NewPubSubBackendNATS(conn, "hlive.", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make sure the server has the subscription before we publish
+	if err := conn.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	ps := hlivekit.NewPubSub(hlivekit.PubSubOptionBackend(backend))
+	t.Cleanup(ps.Close)
+
+	return ps
+}
+
+func TestPubSubBackendNATS(t *testing.T) {
+	t.Parallel()
+
+	s := newNATSServer(t)
+
+	// Like two server processes
+	psA := newNATSPubSub(t, s)
+	psB := newNATSPubSub(t, s)
+
+	subA := newSub()
+	subB := newSub()
+
+	psA.SubscribeWait(subA, "chat.room")
+	psB.SubscribeWait(subB, "chat.room")
+
+	psA.Publish("chat.room", map[string]any{"text": "hello"})
+
+	subA.wait.Wait()
+	subB.wait.Wait()
+
+	if diff := deep.Equal("chat.room", subB.calledTopic); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(map[string]any{"text": "hello"}, subB.calledValue); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPubSubBackendNATS_PrefixDot(t *testing.T) {
+	t.Parallel()
+
+	s := newNATSServer(t)
+
+	conn, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(conn.Close)
+
+	backend, err := hlivekit.NewPubSubBackendNATS(conn, "app", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = backend.Close() })
+
+	subject := make(chan string, 1)
+
+	if _, err := conn.Subscribe("app.>", func(msg *nats.Msg) { subject <- msg.Subject }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backend.Publish(hlivekit.QueueMessage{Topic: "chat.room", Value: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-subject:
+		if diff := deep.Equal("app.chat.room", got); diff != nil {
+			t.Error(diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not received")
+	}
+}
+
+func TestPubSubBackendNATS_PrefixWildcard(t *testing.T) {
+	t.Parallel()
+
+	s := newNATSServer(t)
+
+	conn, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(conn.Close)
+
+	for _, prefix := range []string{"app.*.", "app.>", ">"} {
+		if _, err := hlivekit.NewPubSubBackendNATS(conn, prefix, nil); !errors.Is(err, hlivekit.ErrPrefixWildcard) {
+			t.Errorf("prefix %q: want ErrPrefixWildcard, got %v", prefix, err)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package hlivekit
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/SamHennessy/hlive"
+)
+
+// QueuePolicy is what a subscription's queue does when it's full
+type QueuePolicy int
+
+const (
+	// QueuePolicyDropOldest removes the oldest message to make space, it's the default
+	QueuePolicyDropOldest QueuePolicy = iota
+	// QueuePolicyCoalesce replaces a queued message with the new message for the same topic, else drops the oldest.
+	// For UIs that only need the latest value.
+	QueuePolicyCoalesce
+	// QueuePolicyBlock makes Publish wait for space in the queue, so one slow subscriber slows every publisher
+	QueuePolicyBlock
+)
+
+// SubscribeOptions change how messages are delivered to a subscriber
+type SubscribeOptions struct {
+	// QueueSize buffers messages, they're delivered in order from one goroutine so Publish doesn't wait for
+	// OnMessage. Zero calls OnMessage from Publish.
+	QueueSize int
+	// Policy when the queue is full, QueuePolicyDropOldest by default
+	Policy QueuePolicy
+	// Replay sends up to this many of the last messages published to the topics when subscribing,
+	// needs PubSubOptionRetain
+	Replay int
+}
+
+// The default for PubSubOptionRetainTopics
+const defaultRetainTopics = 1000
+
+// PubSubOptionRetain keeps the last n messages of each topic for subscribers that want them replayed
+func PubSubOptionRetain(n int) PubSubOption {
+	return func(ps *PubSub) {
+		ps.retain = n
+	}
+}
+
+// PubSubOptionRetainTopics is how many topics PubSubOptionRetain keeps messages for. When there are more, the
+// topic with the oldest last message is dropped. The default is 1000.
+func PubSubOptionRetainTopics(n int) PubSubOption {
+	return func(ps *PubSub) {
+		ps.retainTopics = n
+	}
+}
+
+// SubscribeWithOptions is like SubscribeWait with options for how messages are delivered.
+// Use UnsubscribeWait with sub to stop.
+//
+// Without a queue, a message published while the replay is running can arrive before the replayed messages.
+func (ps *PubSub) SubscribeWithOptions(sub QueueSubscriber, options SubscribeOptions, topics ...string) {
+	if sub == nil {
+		hlive.LoggerDev.Warn("sub nil", "callers", hlive.CallerStackStr())
+
+		return
+	}
+
+	var queue *subscriberQueue
+	if options.QueueSize > 0 {
+		queue = &subscriberQueue{sub: sub, size: options.QueueSize, policy: options.Policy}
+		queue.cond = sync.NewCond(&queue.mu)
+		sub = queue
+	}
+
+	var replay []QueueMessage
+
+	// Hold the lock so nothing is published between the replay and the subscribe
+	ps.mu.Lock()
+	ps.subscribeLocked(sub, topics)
+
+	if options.Replay > 0 {
+		replay = ps.retained(topics, options.Replay)
+	}
+
+	// Don't block or call OnMessage while holding the lock
+	if queue != nil {
+		for i := 0; i < len(replay); i++ {
+			queue.push(replay[i], false)
+		}
+
+		replay = nil
+	}
+	ps.mu.Unlock()
+
+	for i := 0; i < len(replay); i++ {
+		sub.OnMessage(replay[i])
+	}
+}
+
+type retainedMessage struct {
+	seq     uint64
+	message QueueMessage
+}
+
+func (ps *PubSub) retainMessage(item QueueMessage) {
+	// Replies are only for the request
+	if ps.retain <= 0 || strings.HasPrefix(item.Topic, replyTopicPrefix) {
+		return
+	}
+
+	ps.muRetain.Lock()
+	defer ps.muRetain.Unlock()
+
+	ps.retainSeq++
+
+	if _, exists := ps.retainedMessages[item.Topic]; !exists && len(ps.retainedMessages) >= ps.retainTopics {
+		ps.retainDropOldestLocked()
+	}
+
+	list := append(ps.retainedMessages[item.Topic], retainedMessage{seq: ps.retainSeq, message: item})
+	if len(list) > ps.retain {
+		list = list[len(list)-ps.retain:]
+	}
+
+	ps.retainedMessages[item.Topic] = list
+}
+
+// Drop the topic that was published to least recently, must hold muRetain
+func (ps *PubSub) retainDropOldestLocked() {
+	var (
+		oldest string
+		seq    uint64
+	)
+
+	for topic, list := range ps.retainedMessages {
+		if last := list[len(list)-1].seq; seq == 0 || last < seq {
+			oldest, seq = topic, last
+		}
+	}
+
+	delete(ps.retainedMessages, oldest)
+}
+
+// The last n messages for the topics, oldest first
+func (ps *PubSub) retained(topics []string, n int) []QueueMessage {
+	ps.muRetain.Lock()
+	defer ps.muRetain.Unlock()
+
+	var found []retainedMessage
+
+	for topic, list := range ps.retainedMessages {
+		for i := 0; i < len(topics); i++ {
+			wildcards, ok := topicMatch(topics[i], topic)
+			if !ok {
+				continue
+			}
+
+			for j := 0; j < len(list); j++ {
+				rm := list[j]
+				rm.message.Wildcards = wildcards
+				found = append(found, rm)
+			}
+
+			break
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].seq < found[j].seq
+	})
+
+	if len(found) > n {
+		found = found[len(found)-n:]
+	}
+
+	messages := make([]QueueMessage, len(found))
+	for i := 0; i < len(found); i++ {
+		messages[i] = found[i].message
+	}
+
+	return messages
+}
+
+// Queues messages for a subscriber, a goroutine delivers them while there are any
+type subscriberQueue struct {
+	sub     QueueSubscriber
+	size    int
+	policy  QueuePolicy
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []QueueMessage
+	running bool
+}
+
+func (q *subscriberQueue) GetID() string {
+	return q.sub.GetID()
+}
+
+func (q *subscriberQueue) OnMessage(message QueueMessage) {
+	q.push(message, q.policy == QueuePolicyBlock)
+}
+
+// Add the message, when block is false a full queue drops a message
+func (q *subscriberQueue) push(message QueueMessage, block bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.policy == QueuePolicyCoalesce {
+		for i := 0; i < len(q.queue); i++ {
+			if q.queue[i].Topic == message.Topic {
+				q.queue[i] = message
+
+				return
+			}
+		}
+	}
+
+	for block && len(q.queue) >= q.size {
+		q.cond.Wait()
+	}
+
+	if len(q.queue) >= q.size {
+		q.queue = q.queue[1:]
+	}
+
+	q.queue = append(q.queue, message)
+
+	if !q.running {
+		q.running = true
+
+		go q.deliver()
+	}
+}
+
+func (q *subscriberQueue) deliver() {
+	for {
+		q.mu.Lock()
+		if len(q.queue) == 0 {
+			q.running = false
+			q.mu.Unlock()
+
+			return
+		}
+
+		message := q.queue[0]
+		q.queue = q.queue[1:]
+		q.cond.Broadcast()
+		q.mu.Unlock()
+
+		q.sub.OnMessage(message)
+	}
+}
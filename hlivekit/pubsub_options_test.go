@@ -0,0 +1,356 @@
+package hlivekit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SamHennessy/hlive/hlivekit"
+	"github.com/go-test/deep"
+)
+
+// Records messages, blocks until release is closed
+type slowSubscriber struct {
+	id      string
+	release chan struct{}
+	mu      sync.Mutex
+	values  []any
+	done    chan struct{}
+	want    int
+}
+
+func newSlowSub(want int) *slowSubscriber {
+	return &slowSubscriber{
+		id:      "slow",
+		release: make(chan struct{}),
+		done:    make(chan struct{}),
+		want:    want,
+	}
+}
+
+func (s *slowSubscriber) GetID() string {
+	return s.id
+}
+
+func (s *slowSubscriber) OnMessage(message hlivekit.QueueMessage) {
+	<-s.release
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values = append(s.values, message.Value)
+	if len(s.values) == s.want {
+		close(s.done)
+	}
+}
+
+func (s *slowSubscriber) wait(t *testing.T) []any {
+	t.Helper()
+
+	select {
+	case <-s.done:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for messages")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]any{}, s.values...)
+}
+
+func TestPubSub_SubscribeWithOptions_Ordered(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub()
+	sub := newSlowSub(100)
+	close(sub.release)
+
+	ps.SubscribeWithOptions(sub, hlivekit.SubscribeOptions{QueueSize: 10, Policy: hlivekit.QueuePolicyBlock},
+		"topic_1")
+
+	var want []any
+	for i := 0; i < 100; i++ {
+		ps.Publish("topic_1", i)
+		want = append(want, i)
+	}
+
+	if diff := deep.Equal(want, sub.wait(t)); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPubSub_SubscribeWithOptions_DropOldest(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub()
+	// The first message is taken by the goroutine and waits on release
+	sub := newSlowSub(3)
+
+	ps.SubscribeWithOptions(sub, hlivekit.SubscribeOptions{QueueSize: 2, Policy: hlivekit.QueuePolicyDropOldest},
+		"topic_1")
+
+	ps.Publish("topic_1", 0)
+
+	// Wait for the goroutine to take it
+	time.Sleep(10 * time.Millisecond)
+
+	for i := 1; i < 5; i++ {
+		ps.Publish("topic_1", i)
+	}
+
+	close(sub.release)
+
+	if diff := deep.Equal([]any{0, 3, 4}, sub.wait(t)); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPubSub_SubscribeWithOptions_Coalesce(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub()
+	sub := newSlowSub(3)
+
+	ps.SubscribeWithOptions(sub, hlivekit.SubscribeOptions{QueueSize: 10, Policy: hlivekit.QueuePolicyCoalesce},
+		"price.>")
+
+	ps.Publish("price.a", "a0")
+
+	time.Sleep(10 * time.Millisecond)
+
+	ps.Publish("price.a", "a1")
+	ps.Publish("price.b", "b1")
+	ps.Publish("price.a", "a2")
+
+	close(sub.release)
+
+	if diff := deep.Equal([]any{"a0", "a2", "b1"}, sub.wait(t)); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPubSub_SubscribeWithOptions_Replay(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub(hlivekit.PubSubOptionRetain(2))
+
+	ps.Publish("chat.a", 1)
+	ps.Publish("chat.b", 2)
+	ps.Publish("chat.a", 3)
+	ps.Publish("chat.a", 4)
+	ps.Publish("other", 5)
+
+	var got []any
+
+	ps.SubscribeWithOptions(hlivekit.NewSub(func(message hlivekit.QueueMessage) {
+		got = append(got, message.Value)
+	}), hlivekit.SubscribeOptions{Replay: 10}, "chat.*")
+
+	// chat.a only keeps 2
+	if diff := deep.Equal([]any{2, 3, 4}, got); diff != nil {
+		t.Error(diff)
+	}
+
+	sub := newSlowSub(2)
+	close(sub.release)
+
+	ps.SubscribeWithOptions(sub, hlivekit.SubscribeOptions{QueueSize: 5, Replay: 2}, "chat.a")
+
+	if diff := deep.Equal([]any{3, 4}, sub.wait(t)); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPubSub_SubscribeWithOptions_Block(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub()
+	sub := newSlowSub(3)
+
+	ps.SubscribeWithOptions(sub, hlivekit.SubscribeOptions{QueueSize: 1, Policy: hlivekit.QueuePolicyBlock},
+		"topic_1")
+
+	ps.Publish("topic_1", 0)
+
+	time.Sleep(10 * time.Millisecond)
+
+	ps.Publish("topic_1", 1)
+
+	published := make(chan struct{})
+
+	go func() {
+		ps.Publish("topic_1", 2)
+		close(published)
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("expected publish to wait for the queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(sub.release)
+
+	if diff := deep.Equal([]any{0, 1, 2}, sub.wait(t)); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPubSub_SubscribeWithOptions_BlockSubscribes(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub()
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	var (
+		mu     sync.Mutex
+		values []any
+	)
+
+	sub := hlivekit.NewSub(func(message hlivekit.QueueMessage) {
+		<-release
+
+		// Needs the lock the waiting publisher used to hold
+		ps.SubscribeWaitFunc(func(hlivekit.QueueMessage) {}, "other")
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		values = append(values, message.Value)
+		if len(values) == 3 {
+			close(done)
+		}
+	})
+
+	ps.SubscribeWithOptions(sub, hlivekit.SubscribeOptions{QueueSize: 1, Policy: hlivekit.QueuePolicyBlock},
+		"topic_1")
+
+	ps.Publish("topic_1", 0)
+
+	time.Sleep(10 * time.Millisecond)
+
+	ps.Publish("topic_1", 1)
+
+	// Waits for space in the queue
+	go ps.Publish("topic_1", 2)
+
+	time.Sleep(10 * time.Millisecond)
+
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("deadlock")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if diff := deep.Equal([]any{0, 1, 2}, values); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPubSub_SubscribeWithOptions_DefaultDrops(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub()
+	sub := newSlowSub(2)
+
+	ps.SubscribeWithOptions(sub, hlivekit.SubscribeOptions{QueueSize: 1}, "topic_1")
+
+	ps.Publish("topic_1", 0)
+
+	time.Sleep(10 * time.Millisecond)
+
+	published := make(chan struct{})
+
+	go func() {
+		ps.Publish("topic_1", 1)
+		ps.Publish("topic_1", 2)
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publish waited for the queue")
+	}
+
+	close(sub.release)
+
+	if diff := deep.Equal([]any{0, 2}, sub.wait(t)); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPubSub_SubscribeWithOptions_RetainTopics(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub(hlivekit.PubSubOptionRetain(2), hlivekit.PubSubOptionRetainTopics(2))
+
+	ps.Publish("chat.a", 1)
+	ps.Publish("chat.b", 2)
+	ps.Publish("chat.a", 3)
+	// Drops chat.b, its last message is the oldest
+	ps.Publish("chat.c", 4)
+
+	var got []any
+
+	ps.SubscribeWithOptions(hlivekit.NewSub(func(message hlivekit.QueueMessage) {
+		got = append(got, message.Value)
+	}), hlivekit.SubscribeOptions{Replay: 10}, "chat.*")
+
+	if diff := deep.Equal([]any{1, 3, 4}, got); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPubSub_SubscribeWithOptions_ReplayOnce(t *testing.T) {
+	t.Parallel()
+
+	const total = 200
+
+	ps := hlivekit.NewPubSub(hlivekit.PubSubOptionRetain(total))
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < total; i++ {
+			ps.Publish("topic_1", i)
+		}
+	}()
+
+	var (
+		mu  sync.Mutex
+		got = map[any]int{}
+	)
+
+	ps.SubscribeWithOptions(hlivekit.NewSub(func(message hlivekit.QueueMessage) {
+		mu.Lock()
+		got[message.Value]++
+		mu.Unlock()
+	}), hlivekit.SubscribeOptions{Replay: total}, "topic_1")
+
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for value, n := range got {
+		if n != 1 {
+			t.Errorf("value %v delivered %d times", value, n)
+		}
+	}
+
+	if diff := deep.Equal(total, len(got)); diff != nil {
+		t.Error(diff)
+	}
+}
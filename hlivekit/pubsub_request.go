@@ -0,0 +1,122 @@
+package hlivekit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SamHennessy/hlive"
+	"github.com/teris-io/shortid"
+)
+
+var (
+	ErrRequestTimeout = errors.New("pubsub request timeout")
+	ErrResponder      = errors.New("pubsub responder error")
+	ErrTopicWildcard  = errors.New("pubsub topic has wildcards")
+)
+
+// RequestTimeoutDefault is used when a Request's context has no deadline
+const RequestTimeoutDefault = 5 * time.Second
+
+// Reply topics, one per Request
+const replyTopicPrefix = "_inbox."
+
+// Reply is published to a request's ReplyTo topic. Error is a string so it can be sent by a backend.
+type Reply struct {
+	Value any
+	Error string
+}
+
+// PubSubOptionRequestTimeout is used when a Request's context has no deadline
+func PubSubOptionRequestTimeout(timeout time.Duration) PubSubOption {
+	return func(ps *PubSub) {
+		ps.requestTimeout = timeout
+	}
+}
+
+// Request publishes value to topic and waits for the first reply. Use Respond to answer requests.
+//
+// Don't call it from OnMessage, it needs to subscribe to the reply topic.
+func (ps *PubSub) Request(ctx context.Context, topic string, value any) (any, error) {
+	if topicHasWildcard(topic) {
+		return nil, fmt.Errorf("%w: %s", ErrTopicWildcard, topic)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := ps.requestTimeout
+		if timeout == 0 {
+			timeout = RequestTimeoutDefault
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+
+		defer cancel()
+	}
+
+	replyTo := replyTopicPrefix + shortid.MustGenerate()
+	replies := make(chan QueueMessage, 1)
+
+	sub := ps.SubscribeWaitFunc(func(message QueueMessage) {
+		// Only the first reply is used
+		select {
+		case replies <- message:
+		default:
+		}
+	}, replyTo)
+
+	defer ps.Unsubscribe(sub, replyTo)
+
+	ps.publish(QueueMessage{Topic: topic, Value: value, ReplyTo: replyTo})
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w: %s: %w", ErrRequestTimeout, topic, ctx.Err())
+	case message := <-replies:
+		reply, err := topicValue[Reply](message.Value)
+		if err != nil {
+			return nil, fmt.Errorf("reply: %w", err)
+		}
+
+		if reply.Error != "" {
+			return nil, fmt.Errorf("%w: %s", ErrResponder, reply.Error)
+		}
+
+		return reply.Value, nil
+	}
+}
+
+// Respond calls fn for each request published to topic and sends what it returns as the reply. Messages without a
+// ReplyTo are ignored. Each call is in its own goroutine so fn can take its time.
+//
+// Use Unsubscribe with the returned SubscribeFunc and topic to stop.
+func (ps *PubSub) Respond(topic string, fn func(message QueueMessage) (any, error)) SubscribeFunc {
+	return ps.SubscribeWaitFunc(func(message QueueMessage) {
+		if message.ReplyTo == "" {
+			return
+		}
+
+		go func() {
+			value, err := fn(message)
+
+			ps.Reply(message, value, err)
+		}()
+	}, topic)
+}
+
+// Reply is for when a subscriber wants to answer a request itself
+func (ps *PubSub) Reply(message QueueMessage, value any, err error) {
+	if message.ReplyTo == "" {
+		hlive.LoggerDev.Warn("message has no ReplyTo", "callers", hlive.CallerStackStr(), "topic", message.Topic)
+
+		return
+	}
+
+	reply := Reply{Value: value}
+	if err != nil {
+		reply.Error = err.Error()
+	}
+
+	ps.Publish(message.ReplyTo, reply)
+}
@@ -0,0 +1,79 @@
+package hlivekit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SamHennessy/hlive/hlivekit"
+	"github.com/go-test/deep"
+)
+
+func TestPubSub_Request(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub()
+
+	ps.Respond("cart.total", func(message hlivekit.QueueMessage) (any, error) {
+		return message.Value.(int) * 2, nil
+	})
+
+	got, err := ps.Request(context.Background(), "cart.total", 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(42, got); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestPubSub_RequestError(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub()
+
+	ps.Respond("cart.total", func(_ hlivekit.QueueMessage) (any, error) {
+		return nil, errors.New("no cart")
+	})
+
+	_, err := ps.Request(context.Background(), "cart.total", nil)
+	if !errors.Is(err, hlivekit.ErrResponder) {
+		t.Fatal("expected responder error, got:", err)
+	}
+}
+
+func TestPubSub_RequestTimeout(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub(hlivekit.PubSubOptionRequestTimeout(10 * time.Millisecond))
+
+	_, err := ps.Request(context.Background(), "nobody", nil)
+	if !errors.Is(err, hlivekit.ErrRequestTimeout) {
+		t.Fatal("expected timeout, got:", err)
+	}
+}
+
+func TestPubSub_RequestNATS(t *testing.T) {
+	t.Parallel()
+
+	s := newNATSServer(t)
+
+	psA := newNATSPubSub(t, s)
+	psB := newNATSPubSub(t, s)
+
+	psB.Respond("cart.total", func(message hlivekit.QueueMessage) (any, error) {
+		return message.Value.(float64) * 2, nil
+	})
+
+	got, err := psA.Request(context.Background(), "cart.total", 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// JSON numbers are float64
+	if diff := deep.Equal(float64(42), got); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -10,11 +10,12 @@ import (
 )
 
 type testSubscriber struct {
-	id          string
-	called      bool
-	calledTopic string
-	calledValue any
-	wait        sync.WaitGroup
+	id              string
+	called          bool
+	calledTopic     string
+	calledValue     any
+	calledWildcards []string
+	wait            sync.WaitGroup
 }
 
 func newSub() *testSubscriber {
@@ -28,6 +29,7 @@ func (s *testSubscriber) OnMessage(message hlivekit.QueueMessage) {
 	s.called = true
 	s.calledTopic = message.Topic
 	s.calledValue = message.Value
+	s.calledWildcards = message.Wildcards
 	s.wait.Done()
 }
 
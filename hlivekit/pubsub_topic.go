@@ -0,0 +1,154 @@
+package hlivekit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/SamHennessy/hlive"
+)
+
+const (
+	topicSeparator    = "."
+	topicWildcardOne  = "*"
+	topicWildcardRest = ">"
+)
+
+// Topic is a PubSub topic for values of type T.
+//
+// Values that aren't a T, for example from a backend using PubSubCodecJSON, are converted using JSON.
+type Topic[T any] struct {
+	Name string
+}
+
+// TopicMessage is a QueueMessage with a typed value
+type TopicMessage[T any] struct {
+	Topic     string
+	Value     T
+	Wildcards []string
+}
+
+func NewTopic[T any](name string) Topic[T] {
+	return Topic[T]{Name: name}
+}
+
+// With replaces each "*" in the topic name with the next token, "orders.*.created" With "42" is "orders.42.created"
+func (t Topic[T]) With(tokens ...string) Topic[T] {
+	parts := strings.Split(t.Name, topicSeparator)
+	for i := 0; i < len(parts) && len(tokens) != 0; i++ {
+		if parts[i] == topicWildcardOne {
+			parts[i] = tokens[0]
+			tokens = tokens[1:]
+		}
+	}
+
+	return Topic[T]{Name: strings.Join(parts, topicSeparator)}
+}
+
+func (t Topic[T]) Publish(ps *PubSub, value T) {
+	ps.Publish(t.Name, value)
+}
+
+// Subscribe is like PubSub.Subscribe, use PubSub.Unsubscribe with the returned SubscribeFunc and t.Name to stop
+func (t Topic[T]) Subscribe(ps *PubSub, fn func(message TopicMessage[T])) SubscribeFunc {
+	return ps.SubscribeFunc(t.onMessage(fn), t.Name)
+}
+
+// SubscribeWait is like PubSub.SubscribeWait
+func (t Topic[T]) SubscribeWait(ps *PubSub, fn func(message TopicMessage[T])) SubscribeFunc {
+	return ps.SubscribeWaitFunc(t.onMessage(fn), t.Name)
+}
+
+// SubscribeWithOptions is like PubSub.SubscribeWithOptions
+func (t Topic[T]) SubscribeWithOptions(ps *PubSub, options SubscribeOptions, fn func(message TopicMessage[T]),
+) SubscribeFunc {
+	sub := NewSub(t.onMessage(fn))
+
+	ps.SubscribeWithOptions(sub, options, t.Name)
+
+	return sub
+}
+
+func (t Topic[T]) onMessage(fn func(message TopicMessage[T])) func(message QueueMessage) {
+	return func(message QueueMessage) {
+		value, err := topicValue[T](message.Value)
+		if err != nil {
+			hlive.LoggerDev.Error("topic: value is not the topic type", "topic", message.Topic, "error", err)
+
+			return
+		}
+
+		fn(TopicMessage[T]{Topic: message.Topic, Value: value, Wildcards: message.Wildcards})
+	}
+}
+
+func topicValue[T any](value any) (T, error) {
+	var v T
+
+	if value == nil {
+		return v, nil
+	}
+
+	if v, ok := value.(T); ok {
+		return v, nil
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return v, fmt.Errorf("json marshal: %w", err)
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return v, fmt.Errorf("json unmarshal %T into %T: %w", value, v, err)
+	}
+
+	return v, nil
+}
+
+// A "*" token anywhere or a ">" token at the end, a ">" before the end is part of the topic name
+func topicHasWildcard(topic string) bool {
+	tokens := strings.Split(topic, topicSeparator)
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i] == topicWildcardOne || tokens[i] == topicWildcardRest && i == len(tokens)-1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The tokens matched by the wildcards in pattern, ">" matches the rest of the topic as one value
+func topicMatch(pattern, topic string) ([]string, bool) {
+	patternTokens := strings.Split(pattern, topicSeparator)
+	topicTokens := strings.Split(topic, topicSeparator)
+
+	var wildcards []string
+
+	for i := 0; i < len(patternTokens); i++ {
+		if patternTokens[i] == topicWildcardRest && i == len(patternTokens)-1 {
+			if i >= len(topicTokens) {
+				return nil, false
+			}
+
+			return append(wildcards, strings.Join(topicTokens[i:], topicSeparator)), true
+		}
+
+		if i >= len(topicTokens) {
+			return nil, false
+		}
+
+		switch patternTokens[i] {
+		case topicWildcardOne:
+			wildcards = append(wildcards, topicTokens[i])
+		case topicTokens[i]:
+		default:
+			return nil, false
+		}
+	}
+
+	if len(patternTokens) != len(topicTokens) {
+		return nil, false
+	}
+
+	return wildcards, true
+}
@@ -0,0 +1,158 @@
+package hlivekit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/SamHennessy/hlive/hlivekit"
+	"github.com/go-test/deep"
+)
+
+func TestPubSub_Wildcards(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern   string
+		topic     string
+		wildcards []string
+	}{
+		{"orders.*.created", "orders.42.created", []string{"42"}},
+		{"orders.*.created", "orders.42.deleted", nil},
+		{"orders.*.created", "orders.42.created.now", nil},
+		{"orders.>", "orders.42.created", []string{"42.created"}},
+		{"orders.>", "orders", nil},
+		{"*.*", "orders.42", []string{"orders", "42"}},
+		{"*.>", "orders.42.created", []string{"orders", "42.created"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.topic, func(t *testing.T) {
+			t.Parallel()
+
+			sub := newSub()
+			ps := hlivekit.NewPubSub()
+
+			ps.SubscribeWait(sub, tt.pattern)
+
+			ps.Publish(tt.topic, "foo")
+
+			if diff := deep.Equal(tt.wildcards != nil, sub.called); diff != nil {
+				t.Fatal(diff)
+			}
+
+			if !sub.called {
+				return
+			}
+
+			if diff := deep.Equal(tt.topic, sub.calledTopic); diff != nil {
+				t.Error(diff)
+			}
+
+			if diff := deep.Equal(tt.wildcards, sub.calledWildcards); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestPubSub_WildcardUnsubscribe(t *testing.T) {
+	t.Parallel()
+
+	sub := newSub()
+	ps := hlivekit.NewPubSub()
+
+	ps.SubscribeWait(sub, "orders.>")
+	ps.UnsubscribeWait(sub, "orders.>")
+
+	ps.Publish("orders.42", nil)
+
+	if sub.called {
+		t.Fatal("unexpected sub call")
+	}
+}
+
+func TestPubSub_WildcardTopicNames(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub()
+
+	// A ">" before the end is part of the name
+	exact := newSub()
+	ps.SubscribeWait(exact, "a.>.b")
+
+	ps.Publish("a.x.b", nil)
+
+	if exact.called {
+		t.Fatal("unexpected sub call")
+	}
+
+	ps.Publish("a.>.b", nil)
+
+	if diff := deep.Equal("a.>.b", exact.calledTopic); diff != nil {
+		t.Error(diff)
+	}
+
+	pattern := newSub()
+	ps.SubscribeWait(pattern, "orders.*")
+
+	ps.Publish("orders.*", nil)
+
+	if pattern.called {
+		t.Error("published to a topic with wildcards")
+	}
+
+	if _, err := ps.Request(context.Background(), "orders.*", nil); !errors.Is(err, hlivekit.ErrTopicWildcard) {
+		t.Errorf("expected ErrTopicWildcard, got %v", err)
+	}
+}
+
+type order struct {
+	ID    string
+	Total int
+}
+
+func TestTopic(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub()
+	created := hlivekit.NewTopic[order]("orders.*.created")
+
+	var got hlivekit.TopicMessage[order]
+
+	created.SubscribeWait(ps, func(message hlivekit.TopicMessage[order]) {
+		got = message
+	})
+
+	created.With("42").Publish(ps, order{ID: "42", Total: 10})
+
+	want := hlivekit.TopicMessage[order]{
+		Topic:     "orders.42.created",
+		Value:     order{ID: "42", Total: 10},
+		Wildcards: []string{"42"},
+	}
+
+	if diff := deep.Equal(want, got); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestTopic_ConvertsValue(t *testing.T) {
+	t.Parallel()
+
+	ps := hlivekit.NewPubSub()
+	topic := hlivekit.NewTopic[order]("orders")
+
+	var got order
+
+	topic.SubscribeWait(ps, func(message hlivekit.TopicMessage[order]) {
+		got = message.Value
+	})
+
+	// Like a value from PubSubCodecJSON
+	ps.Publish("orders", map[string]any{"ID": "42", "Total": 10})
+
+	if diff := deep.Equal(order{ID: "42", Total: 10}, got); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -35,10 +35,10 @@ func (a *ScrollIntoViewAttribute) Initialize(page *l.Page) {
 		return
 	}
 
-	page.DOM().Head().Add(l.T("script", l.HTML(ScrollIntoViewJavaScript)))
+	page.ScriptAdd(ScrollIntoViewJavaScript)
 }
 
 func (a *ScrollIntoViewAttribute) InitializeSSR(page *l.Page) {
 	a.rendered = true
-	page.DOM().Head().Add(l.T("script", l.HTML(ScrollIntoViewJavaScript)))
+	page.ScriptAdd(ScrollIntoViewJavaScript)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"sync/atomic"
 	"testing"
 
 	l "github.com/SamHennessy/hlive"
@@ -19,22 +20,26 @@ const (
 	ackIDAttrName = "data-hlive-test-ack-id"
 	ackExtraKey   = "test-ack-id"
 	ackCtxKey     = "browser_testing.ack"
+	// Sent after the first render of a connection
+	ackMountID = "mount"
 )
 
 func Ack() l.Attributer {
 	return &ack{
-		l.NewAttribute(ackAttrName, ""),
+		Attribute: l.NewAttribute(ackAttrName, ""),
 	}
 }
 
 type ack struct {
 	*l.Attribute
+
+	mounted atomic.Bool
 }
 
 func (a *ack) Initialize(page *l.Page) {
 	page.HookBeforeEventAdd(ackBeforeEvent)
-	page.HookAfterRenderAdd(ackAfterRender)
-	page.DOM().Head().Add(l.T("script", l.HTML(ackJavaScript)))
+	page.HookAfterRenderAdd(a.afterRender)
+	page.ScriptAdd(ackJavaScript)
 }
 
 func (a *ack) InitializeSSR(_ *l.Page) {}
@@ -48,11 +53,15 @@ func ackBeforeEvent(ctx context.Context, e l.Event) (context.Context, l.Event) {
 	return ctx, e
 }
 
-// If ack id in context then send a message
-func ackAfterRender(ctx context.Context, diffs []l.Diff, send chan<- l.MessageWS) {
+// If ack id in context then send a message, the first render is acknowledged too
+func (a *ack) afterRender(ctx context.Context, diffs []l.Diff, send chan<- l.MessageWS) {
 	ackID, ok := ctx.Value(ackCtxKey).(string)
 	if !ok || ackID == "" {
-		return
+		if a.mounted.Swap(true) {
+			return
+		}
+
+		ackID = ackMountID
 	}
 
 	send <- l.MessageWS{Message: []byte("ack|" + ackID + "\n")}
@@ -0,0 +1,1095 @@
+package hlivetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/SamHennessy/hlive/internal/domapply"
+	"github.com/gorilla/websocket"
+	"golang.org/x/net/html"
+)
+
+var (
+	ErrClientTimeout  = errors.New("timeout waiting for page")
+	ErrClientNotFound = errors.New("element not found")
+)
+
+// How long without a message before a Page without Ack is considered rendered
+const clientSettleDelay = 50 * time.Millisecond
+
+// Client is a headless browser for testing Pages without Playwright.
+//
+// It loads the server side render, connects over a WebSocket, and applies the diffs it receives to its DOM the same
+// way page.js does. No JavaScript is run.
+//
+// Actions wait for the Page to render. If the Page has Ack, like pages from NewServer, Client waits for the
+// acknowledgement, otherwise it waits for the WebSocket to go quiet.
+type Client struct {
+	// How long to wait for the Page
+	Timeout time.Duration
+
+	t    *testing.T
+	http *http.Client
+
+	mu       sync.Mutex
+	conn     *websocket.Conn
+	doc      *html.Node
+	url      *url.URL
+	sessID   string
+	ackMode  bool
+	acks     map[string]bool
+	ackSeq   int
+	values   map[*html.Node]string
+	checked  map[*html.Node]bool
+	selected map[*html.Node]bool
+	focus    *html.Node
+	changed  chan struct{}
+	err      error
+	closed   bool
+}
+
+// Connect loads the Page at rawURL and connects to it
+func Connect(t *testing.T, rawURL string) *Client {
+	t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	FatalOnErr(t, err)
+
+	c := &Client{
+		Timeout: 5 * time.Second,
+		t:       t,
+		http:    &http.Client{Jar: jar},
+		changed: make(chan struct{}),
+	}
+
+	t.Cleanup(c.Close)
+
+	FatalOnErr(t, c.load(rawURL))
+
+	return c
+}
+
+// Close the WebSocket connection
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+}
+
+// Do a full page load and connect the WebSocket
+func (c *Client) load(rawURL string) error {
+	c.mu.Lock()
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+	c.mu.Unlock()
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("parse url: %w", err)
+	}
+
+	res, err := c.http.Get(u.String())
+	if err != nil {
+		return fmt.Errorf("get page: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("get page: %s: %s", u, res.Status)
+	}
+
+	doc, err := html.Parse(res.Body)
+	if err != nil {
+		return fmt.Errorf("parse page: %w", err)
+	}
+
+	lateHead(doc)
+
+	ack, _ := parseSelector("[" + ackAttrName + "]")
+
+	c.mu.Lock()
+	c.doc = doc
+	c.url = res.Request.URL
+	c.sessID = "1"
+	c.ackMode = len(querySelectorAll(doc, ack)) != 0
+	c.acks = map[string]bool{}
+	c.values = map[*html.Node]string{}
+	c.checked = map[*html.Node]bool{}
+	c.selected = map[*html.Node]bool{}
+	c.focus = nil
+	c.err = nil
+	c.mu.Unlock()
+
+	if err := c.dial(); err != nil {
+		return err
+	}
+
+	return c.waitRender(ackMountID)
+}
+
+// Connect like page.js does
+func (c *Client) dial() error {
+	c.mu.Lock()
+	wsURL := *c.url
+	wsURL.Fragment = ""
+	wsURL.Scheme = "ws"
+
+	if c.url.Scheme == "https" {
+		wsURL.Scheme = "wss"
+	}
+
+	q := wsURL.Query()
+	q.Set("hlive", c.sessID)
+
+	root := htmlElement(c.doc)
+	if root != nil {
+		if hash, ok := nodeAttr(root, l.PageHashAttr); ok {
+			q.Set("hhash", hash)
+		}
+
+		if token, ok := nodeAttr(root, l.AttrCSRF); ok {
+			q.Set("hcsrf", token)
+		}
+	}
+
+	nonce, _ := parseSelector("script[nonce]")
+	if scripts := querySelectorAll(c.doc, nonce); len(scripts) != 0 {
+		val, _ := nodeAttr(scripts[0], "nonce")
+		q.Set("hnonce", val)
+	}
+
+	wsURL.RawQuery = q.Encode()
+	origin := c.url.Scheme + "://" + c.url.Host
+	c.mu.Unlock()
+
+	dialer := *websocket.DefaultDialer
+	dialer.Jar = c.http.Jar
+
+	conn, res, err := dialer.Dial(wsURL.String(), http.Header{"Origin": []string{origin}})
+	if err != nil {
+		if res != nil {
+			return fmt.Errorf("dial: %s: %w", res.Status, err)
+		}
+
+		return fmt.Errorf("dial: %w", err)
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		_ = conn.Close()
+
+		return nil
+	}
+
+	c.conn = conn
+	c.mu.Unlock()
+
+	go c.readLoop(conn)
+
+	return nil
+}
+
+func (c *Client) readLoop(conn *websocket.Conn) {
+	for {
+		_, data, err := conn.ReadMessage()
+
+		c.mu.Lock()
+		// Replaced by a page load
+		if c.conn != conn {
+			c.mu.Unlock()
+
+			return
+		}
+
+		if err != nil {
+			c.err = fmt.Errorf("read: %w", err)
+			c.notify()
+			c.mu.Unlock()
+
+			return
+		}
+
+		href, err := c.processMessage(string(data))
+		if err != nil && c.err == nil {
+			c.err = err
+		}
+
+		c.notify()
+		c.mu.Unlock()
+
+		// No live navigation, do a normal page load
+		if href != "" {
+			if err := c.load(href); err != nil {
+				c.mu.Lock()
+				c.err = err
+				c.notify()
+				c.mu.Unlock()
+			}
+
+			return
+		}
+	}
+}
+
+// Returns the URL to load when the server asks for a normal page load
+func (c *Client) processMessage(data string) (string, error) {
+	messages := strings.Split(data, "\n")
+	for i := 0; i < len(messages); i++ {
+		msg := messages[i]
+		if msg == "" {
+			continue
+		}
+
+		parts := strings.Split(msg, "|")
+
+		switch parts[0] {
+		// DOM Diffs
+		case "d":
+			if len(parts) != 6 {
+				return "", fmt.Errorf("invalid diff message format: %s", msg)
+			}
+
+			if err := c.applyDiff(parts); err != nil {
+				return "", fmt.Errorf("apply diff: %s: %w", msg, err)
+			}
+		// Sessions
+		case "s":
+			if len(parts) == 3 {
+				c.sessID = parts[2]
+			}
+		// Navigation
+		case "n":
+			if len(parts) != 3 {
+				return "", fmt.Errorf("invalid navigation message format: %s", msg)
+			}
+
+			href, err := base64Decode(parts[2])
+			if err != nil {
+				return "", fmt.Errorf("navigation: %w", err)
+			}
+
+			u, err := c.url.Parse(href)
+			if err != nil {
+				return "", fmt.Errorf("navigation: %w", err)
+			}
+
+			if parts[1] == "l" {
+				return u.String(), nil
+			}
+
+			c.url = u
+		// Resync
+		case "y":
+			if len(parts) != 3 {
+				continue
+			}
+
+			switch parts[1] {
+			case "r":
+				body, err := base64Decode(parts[2])
+				if err != nil {
+					return "", fmt.Errorf("resync: %w", err)
+				}
+
+				if err := c.replaceBody(body); err != nil {
+					return "", fmt.Errorf("resync: %w", err)
+				}
+			case "q":
+				if err := c.hydrationReport(); err != nil {
+					return "", fmt.Errorf("hydration report: %w", err)
+				}
+			}
+		case "ack":
+			if len(parts) == 2 {
+				c.acks[parts[1]] = true
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// Replace the body with one from the server, must hold the lock
+func (c *Client) replaceBody(body string) error {
+	doc, err := html.Parse(strings.NewReader("<!doctype html><html><head></head>" + body + "</html>"))
+	if err != nil {
+		return fmt.Errorf("parse body: %w", err)
+	}
+
+	list, _ := parseSelector("body")
+
+	newBodies := querySelectorAll(doc, list)
+	oldBodies := querySelectorAll(c.doc, list)
+
+	if len(newBodies) == 0 || len(oldBodies) == 0 {
+		return ErrDiffTarget
+	}
+
+	newBodies[0].Parent.RemoveChild(newBodies[0])
+	oldBodies[0].Parent.InsertBefore(newBodies[0], oldBodies[0])
+	oldBodies[0].Parent.RemoveChild(oldBodies[0])
+
+	c.values = map[*html.Node]string{}
+	c.checked = map[*html.Node]bool{}
+	c.selected = map[*html.Node]bool{}
+	c.focus = nil
+
+	return nil
+}
+
+// Send our body like hlive.hydrationReport, must hold the lock
+func (c *Client) hydrationReport() error {
+	list, _ := parseSelector("body")
+
+	bodies := querySelectorAll(c.doc, list)
+	if len(bodies) == 0 {
+		return ErrDiffTarget
+	}
+
+	buf := &strings.Builder{}
+	for child := bodies[0].FirstChild; child != nil; child = child.NextSibling {
+		if err := html.Render(buf, child); err != nil {
+			return fmt.Errorf("render body: %w", err)
+		}
+	}
+
+	b, err := json.Marshal(clientMessage{Typ: "h", Data: map[string]string{"b": buf.String()}})
+	if err != nil {
+		return fmt.Errorf("json marshal: %w", err)
+	}
+
+	if err := c.conn.WriteMessage(websocket.TextMessage, b); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
+
+// Wake anyone waiting, must hold the lock
+func (c *Client) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// WaitFor waits until cond returns true, cond is called after each message from the server
+func (c *Client) WaitFor(cond func(c *Client) bool) {
+	c.t.Helper()
+
+	FatalOnErr(c.t, c.wait(func() bool {
+		return cond(c)
+	}))
+}
+
+func (c *Client) wait(cond func() bool) error {
+	timeout := time.NewTimer(c.Timeout)
+	defer timeout.Stop()
+
+	for {
+		c.mu.Lock()
+		changed := c.changed
+		err := c.err
+		c.mu.Unlock()
+
+		if cond() {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-changed:
+		case <-timeout.C:
+			return ErrClientTimeout
+		}
+	}
+}
+
+// Wait for the render acknowledgements or for the connection to go quiet
+func (c *Client) waitRender(ackIDs ...string) error {
+	c.mu.Lock()
+	ackMode := c.ackMode
+	c.mu.Unlock()
+
+	if ackMode {
+		return c.wait(func() bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			for i := 0; i < len(ackIDs); i++ {
+				if !c.acks[ackIDs[i]] {
+					return false
+				}
+			}
+
+			return true
+		})
+	}
+
+	timeout := time.NewTimer(c.Timeout)
+	defer timeout.Stop()
+
+	for {
+		c.mu.Lock()
+		changed := c.changed
+		err := c.err
+		c.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-changed:
+		case <-time.After(clientSettleDelay):
+			return nil
+		case <-timeout.C:
+			return ErrClientTimeout
+		}
+	}
+}
+
+type clientMessage struct {
+	Typ        string            `json:"t"`
+	ID         string            `json:"i,omitempty"`
+	Data       map[string]string `json:"d,omitempty"`
+	ValueMulti []string          `json:"vm,omitempty"`
+	Selected   bool              `json:"s,omitempty"`
+	Extra      map[string]string `json:"e,omitempty"`
+}
+
+// Send the messages and wait for the Page to render
+func (c *Client) send(msgs []clientMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	var ackIDs []string
+
+	c.mu.Lock()
+	conn := c.conn
+	ackMode := c.ackMode
+
+	for i := 0; i < len(msgs); i++ {
+		if ackMode && msgs[i].Typ == "e" {
+			c.ackSeq++
+			id := "c" + strconv.Itoa(c.ackSeq)
+			msgs[i].Extra = map[string]string{ackExtraKey: id}
+			ackIDs = append(ackIDs, id)
+		}
+	}
+	c.mu.Unlock()
+
+	if conn == nil {
+		return errors.New("not connected")
+	}
+
+	for i := 0; i < len(msgs); i++ {
+		b, err := json.Marshal(msgs[i])
+		if err != nil {
+			return fmt.Errorf("json marshal: %w", err)
+		}
+
+		c.mu.Lock()
+		err = conn.WriteMessage(websocket.TextMessage, b)
+		c.mu.Unlock()
+
+		if err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+
+		// The server handles each message in its own goroutine, wait to keep them in order
+		if ackMode && msgs[i].Typ == "e" {
+			if err := c.waitRender(ackIDs[0]); err != nil {
+				return err
+			}
+
+			ackIDs = ackIDs[1:]
+		} else if !ackMode {
+			if err := c.waitRender(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Click the first element that matches selector.
+//
+// The click bubbles up to the element's ancestors. Checkboxes and radios are checked, and links in a
+// data-hlive-nav element do a live navigation.
+func (c *Client) Click(selector string) {
+	c.t.Helper()
+
+	c.mu.Lock()
+	el, err := c.find(selector)
+	if err != nil {
+		c.mu.Unlock()
+		c.t.Fatal(err)
+	}
+
+	var (
+		toggled  bool
+		oldCheck = c.isChecked(el)
+	)
+
+	if el.Data == "input" {
+		switch inputType(el) {
+		case "checkbox":
+			c.checked[el] = !oldCheck
+			toggled = true
+		case "radio":
+			c.setRadio(el)
+			toggled = true
+		}
+	}
+
+	msgs, prevented := c.dispatch(el, "click", nil)
+
+	if prevented && toggled {
+		c.checked[el] = oldCheck
+	}
+
+	href := ""
+	if !prevented {
+		href = c.navHref(el)
+	}
+	c.mu.Unlock()
+
+	FatalOnErr(c.t, c.send(msgs))
+
+	if href != "" {
+		c.Navigate(href)
+	}
+}
+
+// Type text into the first element that matches selector, one key at a time
+func (c *Client) Type(selector string, text string) {
+	c.t.Helper()
+
+	c.mu.Lock()
+	el, err := c.find(selector)
+	if err == nil {
+		c.focus = el
+	}
+	c.mu.Unlock()
+
+	FatalOnErr(c.t, err)
+
+	for _, r := range text {
+		key := string(r)
+		code := strconv.Itoa(int(strings.ToUpper(key)[0]))
+
+		c.event(el, "keydown", map[string]string{"key": key, "keyCode": code, "charCode": "0"})
+		char := strconv.Itoa(int(r))
+
+		c.event(el, "keypress", map[string]string{"key": key, "keyCode": char, "charCode": char})
+
+		c.mu.Lock()
+		c.values[el] = c.value(el) + key
+		c.mu.Unlock()
+
+		c.event(el, "input", nil)
+		c.event(el, "keyup", map[string]string{"key": key, "keyCode": code, "charCode": "0"})
+	}
+}
+
+// Select the options with the given values in the first select element that matches selector
+func (c *Client) Select(selector string, values ...string) {
+	c.t.Helper()
+
+	c.mu.Lock()
+	el, err := c.find(selector)
+	if err == nil && el.Data != "select" {
+		err = fmt.Errorf("not a select element: %s", selector)
+	}
+
+	if err == nil {
+		options := c.options(el)
+		for i := 0; i < len(options); i++ {
+			c.selected[options[i]] = contains(values, optionValue(options[i]))
+		}
+	}
+	c.mu.Unlock()
+
+	FatalOnErr(c.t, err)
+
+	c.event(el, "input", nil)
+	c.event(el, "change", nil)
+}
+
+// Dispatch an event of type eventType on the first element that matches selector, e.g. "change" or "focus"
+func (c *Client) Dispatch(selector string, eventType string) {
+	c.t.Helper()
+
+	c.mu.Lock()
+	el, err := c.find(selector)
+	c.mu.Unlock()
+
+	FatalOnErr(c.t, err)
+
+	c.event(el, eventType, nil)
+}
+
+func (c *Client) event(el *html.Node, eventType string, data map[string]string) {
+	c.t.Helper()
+
+	c.mu.Lock()
+	msgs, _ := c.dispatch(el, eventType, data)
+	c.mu.Unlock()
+
+	FatalOnErr(c.t, c.send(msgs))
+}
+
+// Create the event messages for el and its ancestors, must hold the lock
+func (c *Client) dispatch(el *html.Node, eventType string, data map[string]string) ([]clientMessage, bool) {
+	var (
+		msgs      []clientMessage
+		prevented bool
+	)
+
+	for n := el; n != nil; n = n.Parent {
+		if n.Type != html.ElementNode {
+			continue
+		}
+
+		hon, _ := nodeAttr(n, "hon")
+		if hon == "" {
+			continue
+		}
+
+		hit := false
+
+		pairs := strings.Split(hon, ",")
+		for i := 0; i < len(pairs); i++ {
+			parts := strings.Split(pairs[i], "|")
+			if len(parts) != 2 || !strings.EqualFold(parts[1], eventType) {
+				continue
+			}
+
+			hit = true
+
+			msgs = append(msgs, c.eventMessage(n, parts[0], eventType, data))
+		}
+
+		if !hit {
+			continue
+		}
+
+		if _, ok := nodeAttr(n, l.PreventDefaultAttributeName); ok {
+			prevented = true
+		}
+
+		if _, ok := nodeAttr(n, l.StopPropagationAttributeName); ok {
+			break
+		}
+	}
+
+	return msgs, prevented
+}
+
+// Like hlive.eventHandlerHelper, must hold the lock
+func (c *Client) eventMessage(el *html.Node, id string, eventType string, data map[string]string) clientMessage {
+	msg := clientMessage{
+		Typ:  "e",
+		ID:   id,
+		Data: map[string]string{"type": eventType},
+	}
+
+	if hasValue(el) {
+		msg.Data["value"] = c.value(el)
+	}
+
+	if el.Data == "option" && c.isSelected(el) || el.Data == "input" && c.isChecked(el) {
+		msg.Selected = true
+	}
+
+	if el.Data == "select" {
+		options := c.options(el)
+		for i := 0; i < len(options); i++ {
+			if c.isSelected(options[i]) {
+				msg.ValueMulti = append(msg.ValueMulti, optionValue(options[i]))
+			}
+		}
+	}
+
+	if data != nil {
+		msg.Data["shiftKey"] = "false"
+		msg.Data["altKey"] = "false"
+		msg.Data["ctrlKey"] = "false"
+
+		for k, v := range data {
+			msg.Data[k] = v
+		}
+	}
+
+	return msg
+}
+
+// Navigate does a live navigation to href like a link in a data-hlive-nav element
+func (c *Client) Navigate(href string) {
+	c.t.Helper()
+
+	c.mu.Lock()
+	u, err := c.url.Parse(href)
+	if err != nil {
+		c.mu.Unlock()
+		c.t.Fatal(err)
+	}
+
+	conn := c.conn
+	c.url = u
+	delete(c.acks, ackMountID)
+	c.mu.Unlock()
+
+	if conn == nil {
+		FatalOnErr(c.t, c.load(u.String()))
+
+		return
+	}
+
+	FatalOnErr(c.t, c.send([]clientMessage{{Typ: "n", Data: map[string]string{"u": u.RequestURI()}}}))
+
+	c.mu.Lock()
+	ackMode := c.ackMode
+	c.mu.Unlock()
+
+	if ackMode {
+		FatalOnErr(c.t, c.waitRender(ackMountID))
+	}
+}
+
+// The link to live navigate to, like hlive.navClickHandler, must hold the lock
+func (c *Client) navHref(el *html.Node) string {
+	a := closest(el, func(n *html.Node) bool {
+		_, ok := nodeAttr(n, "href")
+
+		return n.Data == "a" && ok
+	})
+	if a == nil {
+		return ""
+	}
+
+	if closest(a, func(n *html.Node) bool {
+		_, ok := nodeAttr(n, l.AttrNav)
+
+		return ok
+	}) == nil {
+		return ""
+	}
+
+	if _, ok := nodeAttr(a, "download"); ok {
+		return ""
+	}
+
+	if target, _ := nodeAttr(a, "target"); target != "" && target != "_self" {
+		return ""
+	}
+
+	href, _ := nodeAttr(a, "href")
+
+	u, err := c.url.Parse(href)
+	if err != nil || u.Scheme != c.url.Scheme || u.Host != c.url.Host {
+		return ""
+	}
+
+	// In page anchors
+	if u.Fragment != "" && u.Path == c.url.Path && u.RawQuery == c.url.RawQuery {
+		return ""
+	}
+
+	return u.String()
+}
+
+// URL is the current URL of the Page
+func (c *Client) URL() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.url.String()
+}
+
+// Text is the text content of the first element that matches selector
+func (c *Client) Text(selector string) string {
+	c.t.Helper()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, err := c.find(selector)
+	FatalOnErr(c.t, err)
+
+	return textContent(el)
+}
+
+// Attr is the value of the attribute name of the first element that matches selector
+func (c *Client) Attr(selector string, name string) string {
+	c.t.Helper()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, err := c.find(selector)
+	FatalOnErr(c.t, err)
+
+	val, _ := nodeAttr(el, name)
+
+	return val
+}
+
+// Value is the current value of the first form element that matches selector
+func (c *Client) Value(selector string) string {
+	c.t.Helper()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, err := c.find(selector)
+	FatalOnErr(c.t, err)
+
+	return c.value(el)
+}
+
+// Checked is true if the first element that matches selector is checked
+func (c *Client) Checked(selector string) bool {
+	c.t.Helper()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, err := c.find(selector)
+	FatalOnErr(c.t, err)
+
+	return c.isChecked(el)
+}
+
+// Count the elements that match selector
+func (c *Client) Count(selector string) int {
+	c.t.Helper()
+
+	list, err := parseSelector(selector)
+	FatalOnErr(c.t, err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(querySelectorAll(c.doc, list))
+}
+
+// HTML renders the current DOM
+func (c *Client) HTML() string {
+	c.t.Helper()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	buf := bytes.NewBuffer(nil)
+	FatalOnErr(c.t, html.Render(buf, c.doc))
+
+	return buf.String()
+}
+
+// Must hold the lock
+func (c *Client) find(selector string) (*html.Node, error) {
+	list, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	found := querySelectorAll(c.doc, list)
+	if len(found) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotFound, selector)
+	}
+
+	return found[0], nil
+}
+
+// The value property, must hold the lock
+func (c *Client) value(el *html.Node) string {
+	if val, ok := c.values[el]; ok {
+		return val
+	}
+
+	switch el.Data {
+	case "textarea":
+		return textContent(el)
+	case "select":
+		options := c.options(el)
+		for i := 0; i < len(options); i++ {
+			if c.isSelected(options[i]) {
+				return optionValue(options[i])
+			}
+		}
+
+		return ""
+	case "option":
+		return optionValue(el)
+	}
+
+	val, _ := nodeAttr(el, "value")
+
+	return val
+}
+
+// Must hold the lock
+func (c *Client) isChecked(el *html.Node) bool {
+	if checked, ok := c.checked[el]; ok {
+		return checked
+	}
+
+	_, ok := nodeAttr(el, "checked")
+
+	return ok
+}
+
+// Must hold the lock
+func (c *Client) isSelected(el *html.Node) bool {
+	if selected, ok := c.selected[el]; ok {
+		return selected
+	}
+
+	_, ok := nodeAttr(el, "selected")
+
+	return ok
+}
+
+// Check a radio and uncheck the others in its group, must hold the lock
+func (c *Client) setRadio(el *html.Node) {
+	name, _ := nodeAttr(el, "name")
+
+	if name != "" {
+		list, _ := parseSelector("input[type=radio]")
+		radios := querySelectorAll(c.doc, list)
+
+		for i := 0; i < len(radios); i++ {
+			if other, _ := nodeAttr(radios[i], "name"); other == name {
+				c.checked[radios[i]] = false
+			}
+		}
+	}
+
+	c.checked[el] = true
+}
+
+// Must hold the lock
+func (c *Client) options(el *html.Node) []*html.Node {
+	list, _ := parseSelector("option")
+
+	return querySelectorAll(el, list)
+}
+
+func optionValue(el *html.Node) string {
+	if val, ok := nodeAttr(el, "value"); ok && val != "" {
+		return val
+	}
+
+	return textContent(el)
+}
+
+func inputType(el *html.Node) string {
+	typ, _ := nodeAttr(el, "type")
+
+	return strings.ToLower(typ)
+}
+
+// Elements with a value property that page.js sends
+func hasValue(el *html.Node) bool {
+	switch el.Data {
+	case "input", "textarea", "select", "button", "option", "output":
+		return true
+	}
+
+	return false
+}
+
+func closest(n *html.Node, match func(n *html.Node) bool) *html.Node {
+	for ; n != nil; n = n.Parent {
+		if n.Type == html.ElementNode && match(n) {
+			return n
+		}
+	}
+
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var sb strings.Builder
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode || child.Type == html.ElementNode {
+			sb.WriteString(textContent(child))
+		}
+	}
+
+	return sb.String()
+}
+
+func htmlElement(doc *html.Node) *html.Node {
+	for child := doc.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == "html" {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// Move the late head nodes of a streamed page into the head, like hlive.lateHead
+func lateHead(doc *html.Node) {
+	list, _ := parseSelector("template[" + l.AttrLateHead + "]")
+	templates := querySelectorAll(doc, list)
+
+	head, _ := parseSelector("head")
+	heads := querySelectorAll(doc, head)
+
+	if len(heads) == 0 {
+		return
+	}
+
+	bundle, _ := parseSelector("script[" + l.AttrBundle + "]")
+
+	for i := 0; i < len(templates); i++ {
+		template := templates[i]
+
+		lateBundles := querySelectorAll(template, bundle)
+		if bundles := querySelectorAll(heads[0], bundle); len(bundles) != 0 && len(lateBundles) != 0 {
+			src, _ := nodeAttr(lateBundles[0], "src")
+			domapply.SetAttr(bundles[0], "src", src)
+			lateBundles[0].Parent.RemoveChild(lateBundles[0])
+		}
+
+		for template.FirstChild != nil {
+			child := template.FirstChild
+			template.RemoveChild(child)
+			heads[0].AppendChild(child)
+		}
+
+		template.Parent.RemoveChild(template)
+	}
+}
@@ -0,0 +1,44 @@
+package hlivetest
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/SamHennessy/hlive/internal/domapply"
+	"golang.org/x/net/html"
+)
+
+var ErrDiffTarget = domapply.ErrTarget
+
+// Apply a diff the same way hlive.processMsg does, must hold the lock
+func (c *Client) applyDiff(parts []string) error {
+	return domapply.Diff(c.doc, parts, domapply.Hooks{
+		Value: func(n *html.Node, value string) {
+			// Don't update when someone is typing
+			if n != c.focus || value == "" {
+				c.values[n] = value
+			}
+		},
+		Removed: c.forget,
+	})
+}
+
+// Forget a removed node's state, must hold the lock
+func (c *Client) forget(n *html.Node) {
+	if c.focus == n {
+		c.focus = nil
+	}
+
+	delete(c.values, n)
+	delete(c.checked, n)
+	delete(c.selected, n)
+}
+
+func base64Decode(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("base64 decode: %w", err)
+	}
+
+	return string(b), nil
+}
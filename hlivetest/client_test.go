@@ -0,0 +1,238 @@
+package hlivetest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/SamHennessy/hlive/hlivetest"
+	"github.com/SamHennessy/hlive/hlivetest/pages"
+)
+
+func TestClient_Click(t *testing.T) {
+	t.Parallel()
+
+	s := hlivetest.NewServer(pages.Click())
+	t.Cleanup(s.HTTPServer.Close)
+
+	c := hlivetest.Connect(t, s.HTTPServer.URL)
+
+	hlivetest.Diff(t, "0", c.Text("#count"))
+
+	for i := 0; i < 10; i++ {
+		c.Click("#btn")
+	}
+
+	hlivetest.Diff(t, "10", c.Text("#count"))
+}
+
+func TestClient_ClickNoAck(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(l.NewPageServer(pages.Click()))
+	t.Cleanup(ts.Close)
+
+	c := hlivetest.Connect(t, ts.URL)
+
+	c.Click("#btn")
+
+	c.WaitFor(func(c *hlivetest.Client) bool {
+		return c.Text("#count") == "1"
+	})
+}
+
+func TestClient_ClickBubbles(t *testing.T) {
+	t.Parallel()
+
+	s := hlivetest.NewServer(func() *l.Page {
+		page := l.NewPage()
+
+		count := l.Box(0)
+
+		page.DOM().Body().Add(
+			l.C("div",
+				l.On("click", func(_ context.Context, _ l.Event) {
+					count.Lock(func(val int) int {
+						return val + 1
+					})
+				}),
+				l.T("span", l.Attrs{"id": "inner"}, "Click"),
+			),
+			l.T("p", l.Attrs{"id": "count"}, count),
+		)
+
+		return page
+	})
+	t.Cleanup(s.HTTPServer.Close)
+
+	c := hlivetest.Connect(t, s.HTTPServer.URL)
+
+	c.Click("#inner")
+
+	hlivetest.Diff(t, "1", c.Text("#count"))
+}
+
+func TestClient_Type(t *testing.T) {
+	t.Parallel()
+
+	s := hlivetest.NewServer(func() *l.Page {
+		page := l.NewPage()
+
+		name := l.Box("")
+		keys := l.Box(0)
+
+		page.DOM().Body().Add(
+			l.C("input", l.Attrs{"id": "name"},
+				l.On("input", func(_ context.Context, e l.Event) {
+					name.Set(e.Value)
+				}),
+				l.On("keyup", func(_ context.Context, _ l.Event) {
+					keys.Lock(func(val int) int {
+						return val + 1
+					})
+				}),
+			),
+			l.T("p", l.Attrs{"id": "greet"}, "Hello ", name),
+			l.T("p", l.Attrs{"id": "keys"}, keys),
+		)
+
+		return page
+	})
+	t.Cleanup(s.HTTPServer.Close)
+
+	c := hlivetest.Connect(t, s.HTTPServer.URL)
+
+	c.Type("#name", "Sam")
+
+	hlivetest.Diff(t, "Sam", c.Value("#name"))
+	hlivetest.Diff(t, "Hello Sam", c.Text("#greet"))
+	hlivetest.Diff(t, "3", c.Text("#keys"))
+}
+
+func TestClient_CheckboxAndSelect(t *testing.T) {
+	t.Parallel()
+
+	s := hlivetest.NewServer(func() *l.Page {
+		page := l.NewPage()
+
+		checked := l.Box("false")
+		picked := l.Box("")
+
+		page.DOM().Body().Add(
+			l.C("input", l.Attrs{"id": "agree", "type": "checkbox"},
+				l.On("click", func(_ context.Context, e l.Event) {
+					checked.Set(strconv.FormatBool(e.Selected))
+				}),
+			),
+			l.C("select", l.Attrs{"id": "color", "multiple": ""},
+				l.On("change", func(_ context.Context, e l.Event) {
+					picked.Set(strings.Join(e.Values, ","))
+				}),
+				l.T("option", l.Attrs{"value": "r"}, "Red"),
+				l.T("option", l.Attrs{"value": "g"}, "Green"),
+				l.T("option", "Blue"),
+			),
+			l.T("p", l.Attrs{"id": "checked"}, checked),
+			l.T("p", l.Attrs{"id": "picked"}, picked),
+		)
+
+		return page
+	})
+	t.Cleanup(s.HTTPServer.Close)
+
+	c := hlivetest.Connect(t, s.HTTPServer.URL)
+
+	c.Click("#agree")
+
+	hlivetest.Diff(t, true, c.Checked("#agree"))
+	hlivetest.Diff(t, "true", c.Text("#checked"))
+
+	c.Select("#color", "g", "Blue")
+
+	hlivetest.Diff(t, "g,Blue", c.Text("#picked"))
+}
+
+func TestClient_AddRemove(t *testing.T) {
+	t.Parallel()
+
+	s := hlivetest.NewServer(func() *l.Page {
+		page := l.NewPage()
+
+		count := 0
+		items := l.Box(l.G())
+
+		set := func(n int) {
+			count = n
+
+			g := l.G()
+			for i := 0; i < count; i++ {
+				g.Add(l.T("li", l.Attrs{"class": "item"}, "Item"))
+			}
+
+			items.Set(g)
+		}
+
+		page.DOM().Body().Add(
+			l.C("button", l.Attrs{"id": "add"},
+				l.On("click", func(_ context.Context, _ l.Event) {
+					set(count + 1)
+				}),
+			),
+			l.C("button", l.Attrs{"id": "remove"},
+				l.On("click", func(_ context.Context, _ l.Event) {
+					set(count - 1)
+				}),
+			),
+			l.T("ul", l.Attrs{"id": "list"}, items),
+		)
+
+		return page
+	})
+	t.Cleanup(s.HTTPServer.Close)
+
+	c := hlivetest.Connect(t, s.HTTPServer.URL)
+
+	c.Click("#add")
+	c.Click("#add")
+	c.Click("#add")
+
+	hlivetest.Diff(t, 3, c.Count("#list > li.item"))
+
+	c.Click("#remove")
+
+	hlivetest.Diff(t, 2, c.Count("ul li[class=item]"))
+}
+
+func TestClient_Navigate(t *testing.T) {
+	t.Parallel()
+
+	rt := l.NewRouter()
+	rt.Handle("GET /{$}", func(r *http.Request) *l.Page {
+		page := l.NewPage()
+		page.DOM().HTML().Add(hlivetest.Ack())
+		page.DOM().Body().Add(l.T("nav", l.Nav(), l.T("a", l.Attrs{"id": "about", "href": "/about"}, "About")))
+
+		return page
+	})
+	rt.Handle("GET /about", func(r *http.Request) *l.Page {
+		page := l.NewPage()
+		page.DOM().HTML().Add(hlivetest.Ack())
+		page.DOM().Body().Add(l.T("h1", "About"))
+
+		return page
+	})
+
+	ts := httptest.NewServer(rt)
+	t.Cleanup(ts.Close)
+
+	c := hlivetest.Connect(t, ts.URL)
+
+	c.Click("#about")
+
+	hlivetest.Diff(t, ts.URL+"/about", c.URL())
+	hlivetest.Diff(t, "About", c.Text("h1"))
+}
@@ -0,0 +1,336 @@
+package hlivetest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var ErrSelectorInvalid = errors.New("invalid selector")
+
+// A small CSS selector engine for Client
+//
+// Supported:
+//   - Type, universal, ID and class selectors: div, *, #id, .class
+//   - Attribute selectors: [attr], [attr=v], [attr~=v], [attr^=v], [attr$=v], [attr*=v]
+//   - Descendant and child combinators: "div p", "div > p"
+//   - Selector lists: "h1, h2"
+
+type selectorAttr struct {
+	name  string
+	op    string
+	value string
+}
+
+type selectorCompound struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []selectorAttr
+	// How this compound relates to the one before it, ' ' or '>'
+	combinator byte
+}
+
+// Compounds are in document order, the last one matches the element
+type selectorComplex []selectorCompound
+
+type selectorList []selectorComplex
+
+func parseSelector(selector string) (selectorList, error) {
+	var list selectorList
+
+	parts := strings.Split(selector, ",")
+	for i := 0; i < len(parts); i++ {
+		complexSel, err := parseSelectorComplex(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, selector)
+		}
+
+		list = append(list, complexSel)
+	}
+
+	return list, nil
+}
+
+func parseSelectorComplex(s string) (selectorComplex, error) {
+	var (
+		sel        selectorComplex
+		combinator byte = ' '
+	)
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, ErrSelectorInvalid
+	}
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case ' ', '\t', '\n':
+			i++
+		case '>':
+			if len(sel) == 0 || combinator == '>' {
+				return nil, ErrSelectorInvalid
+			}
+
+			combinator = '>'
+			i++
+		default:
+			compound, n, err := parseSelectorCompound(s[i:])
+			if err != nil {
+				return nil, err
+			}
+
+			compound.combinator = combinator
+			sel = append(sel, compound)
+			combinator = ' '
+			i += n
+		}
+	}
+
+	if combinator == '>' {
+		return nil, ErrSelectorInvalid
+	}
+
+	return sel, nil
+}
+
+// Returns the compound and how many bytes were read
+func parseSelectorCompound(s string) (selectorCompound, int, error) {
+	var c selectorCompound
+
+	i := 0
+
+	// Type or universal
+	if s[0] == '*' {
+		i++
+	} else {
+		name := selectorIdent(s)
+		c.tag = strings.ToLower(name)
+		i += len(name)
+	}
+
+	for i < len(s) {
+		switch s[i] {
+		case '#':
+			name := selectorIdent(s[i+1:])
+			if name == "" {
+				return c, 0, ErrSelectorInvalid
+			}
+
+			c.id = name
+			i += len(name) + 1
+		case '.':
+			name := selectorIdent(s[i+1:])
+			if name == "" {
+				return c, 0, ErrSelectorInvalid
+			}
+
+			c.classes = append(c.classes, name)
+			i += len(name) + 1
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end == -1 {
+				return c, 0, ErrSelectorInvalid
+			}
+
+			attr, err := parseSelectorAttr(s[i+1 : i+end])
+			if err != nil {
+				return c, 0, err
+			}
+
+			c.attrs = append(c.attrs, attr)
+			i += end + 1
+		case ' ', '\t', '\n', '>':
+			return c, i, nil
+		default:
+			return c, 0, ErrSelectorInvalid
+		}
+	}
+
+	if i == 0 {
+		return c, 0, ErrSelectorInvalid
+	}
+
+	return c, i, nil
+}
+
+func parseSelectorAttr(s string) (selectorAttr, error) {
+	var attr selectorAttr
+
+	index := strings.IndexByte(s, '=')
+	if index == -1 {
+		attr.name = strings.ToLower(strings.TrimSpace(s))
+	} else {
+		attr.name = s[:index]
+		attr.op = "="
+
+		if index != 0 && strings.ContainsRune("~^$*", rune(s[index-1])) {
+			attr.name = s[:index-1]
+			attr.op = s[index-1 : index+1]
+		}
+
+		attr.name = strings.ToLower(strings.TrimSpace(attr.name))
+		attr.value = strings.TrimSpace(s[index+1:])
+
+		if len(attr.value) > 1 && (attr.value[0] == '"' || attr.value[0] == '\'') {
+			if attr.value[len(attr.value)-1] != attr.value[0] {
+				return attr, ErrSelectorInvalid
+			}
+
+			attr.value = attr.value[1 : len(attr.value)-1]
+		}
+	}
+
+	if attr.name == "" {
+		return attr, ErrSelectorInvalid
+	}
+
+	return attr, nil
+}
+
+func selectorIdent(s string) string {
+	i := 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80 {
+			continue
+		}
+
+		break
+	}
+
+	return s[:i]
+}
+
+func (list selectorList) match(n *html.Node) bool {
+	for i := 0; i < len(list); i++ {
+		if list[i].match(n, len(list[i])-1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (sel selectorComplex) match(n *html.Node, index int) bool {
+	if !sel[index].match(n) {
+		return false
+	}
+
+	if index == 0 {
+		return true
+	}
+
+	if sel[index].combinator == '>' {
+		return n.Parent != nil && sel.match(n.Parent, index-1)
+	}
+
+	for p := n.Parent; p != nil; p = p.Parent {
+		if sel.match(p, index-1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c selectorCompound) match(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+
+	if c.tag != "" && c.tag != n.Data {
+		return false
+	}
+
+	if c.id != "" {
+		if id, _ := nodeAttr(n, "id"); id != c.id {
+			return false
+		}
+	}
+
+	if len(c.classes) != 0 {
+		class, _ := nodeAttr(n, "class")
+		classes := strings.Fields(class)
+
+		for i := 0; i < len(c.classes); i++ {
+			if !contains(classes, c.classes[i]) {
+				return false
+			}
+		}
+	}
+
+	for i := 0; i < len(c.attrs); i++ {
+		if !c.attrs[i].match(n) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a selectorAttr) match(n *html.Node) bool {
+	val, ok := nodeAttr(n, a.name)
+	if !ok {
+		return false
+	}
+
+	switch a.op {
+	case "":
+		return true
+	case "=":
+		return val == a.value
+	case "~=":
+		return contains(strings.Fields(val), a.value)
+	case "^=":
+		return a.value != "" && strings.HasPrefix(val, a.value)
+	case "$=":
+		return a.value != "" && strings.HasSuffix(val, a.value)
+	case "*=":
+		return a.value != "" && strings.Contains(val, a.value)
+	}
+
+	return false
+}
+
+// Elements that match, in document order
+func querySelectorAll(root *html.Node, list selectorList) []*html.Node {
+	var found []*html.Node
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if list.match(child) {
+				found = append(found, child)
+			}
+
+			walk(child)
+		}
+	}
+
+	walk(root)
+
+	return found
+}
+
+func nodeAttr(n *html.Node, name string) (string, bool) {
+	for i := 0; i < len(n.Attr); i++ {
+		if n.Attr[i].Namespace == "" && n.Attr[i].Key == name {
+			return n.Attr[i].Val, true
+		}
+	}
+
+	return "", false
+}
+
+func contains(list []string, s string) bool {
+	for i := 0; i < len(list); i++ {
+		if list[i] == s {
+			return true
+		}
+	}
+
+	return false
+}
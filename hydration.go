@@ -0,0 +1,145 @@
+package hlive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/net/html"
+)
+
+// When the cache misses the WebSocket connection renders a new Page, check it matches the server side render the
+// browser has. If not, replace the browser's body and ask for what it had, must hold the lock.
+func (p *Page) checkHydration(ctx context.Context, tree *NodeGroup) {
+	if p.hydrationHash == "" || tree == nil {
+		return
+	}
+
+	hhash := p.hydrationHash
+	p.hydrationHash = ""
+
+	// Same hash as PipelineProcessorRenderHashAndCache
+	hasher := sha256.New()
+	if err := p.renderer.HTML(hasher, tree); err != nil {
+		p.logger.Error("hydration: render", "error", err)
+
+		return
+	}
+
+	if fmt.Sprintf("%x", hasher.Sum(nil)) == hhash {
+		return
+	}
+
+	body := p.browserBody()
+	if body == nil {
+		return
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err := p.renderer.HTML(buf, body); err != nil {
+		p.logger.Error("hydration: render body", "error", err)
+
+		return
+	}
+
+	p.logger.Warn("hydration: server side render doesn't match, replacing the browser's body", "hash", hhash)
+
+	p.hydrationBody = buf.String()
+
+	// Ask for the browser's body first so we can compare it with ours
+	p.wsSend(ctx, "y|q|")
+	p.wsSend(ctx, "y|r|"+base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// The browser's body from before we replaced it
+func (p *Page) processMsgHydration(msg websocketMessage) {
+	p.mu.Lock()
+	body := p.hydrationBody
+	p.hydrationBody = ""
+	p.mu.Unlock()
+
+	if body == "" {
+		return
+	}
+
+	path, err := hydrationDiffPath(body, msg.Data["b"])
+	if err != nil {
+		p.logger.Error("hydration: compare", "error", err)
+
+		return
+	}
+
+	LoggerDev.Warn("hydration mismatch: the first WebSocket render doesn't match the server side render, "+
+		"look for content that changes each render", "selector", path, "url", p.URL().String())
+}
+
+// A CSS selector for the first element that's different, or the element with the different text
+func hydrationDiffPath(server, browser string) (string, error) {
+	serverBody, err := parseBody(server)
+	if err != nil {
+		return "", err
+	}
+
+	browserBody, err := parseBody(browser)
+	if err != nil {
+		return "", err
+	}
+
+	path, _ := domDiffPath(serverBody, browserBody, "body")
+
+	return path, nil
+}
+
+func domDiffPath(server, browser *html.Node, path string) (string, bool) {
+	s, b := server.FirstChild, browser.FirstChild
+	nth := 0
+
+	for ; s != nil || b != nil; s, b = s.NextSibling, b.NextSibling {
+		if s == nil || b == nil || s.Type != b.Type {
+			return path, true
+		}
+
+		if s.Type != html.ElementNode {
+			if s.Data != b.Data {
+				return path, true
+			}
+
+			continue
+		}
+
+		nth++
+		childPath := path + " > " + s.Data + ":nth-child(" + strconv.Itoa(nth) + ")"
+
+		if s.Data != b.Data || !sameAttrs(s.Attr, b.Attr) {
+			return childPath, true
+		}
+
+		if found, ok := domDiffPath(s, b, childPath); ok {
+			return found, true
+		}
+	}
+
+	return path, false
+}
+
+func sameAttrs(a, b []html.Attribute) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	m := make(map[string]string, len(a))
+	for i := 0; i < len(a); i++ {
+		m[a[i].Key] = a[i].Val
+	}
+
+	for i := 0; i < len(b); i++ {
+		if val, ok := m[b[i].Key]; !ok || val != b[i].Val {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,192 @@
+package hlive_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/gorilla/websocket"
+)
+
+var hashRegexp = regexp.MustCompile(l.PageHashAttr + `="([^"]+)"`)
+
+// Always a miss, like after a restart
+type missCache struct{}
+
+func (missCache) Get(_ any) (any, bool) { return nil, false }
+func (missCache) Set(_, _ any)          {}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// Not parallel, it replaces LoggerDev
+func TestPage_HydrationMismatch(t *testing.T) {
+	logs := &syncBuffer{}
+
+	loggerDev := l.LoggerDev
+	l.LoggerDev = slog.New(slog.NewTextHandler(logs, nil))
+
+	t.Cleanup(func() { l.LoggerDev = loggerDev })
+
+	renders := 0
+	mu := sync.Mutex{}
+
+	ts := httptest.NewServer(l.NewPageServer(func() *l.Page {
+		mu.Lock()
+		renders++
+		n := renders
+		mu.Unlock()
+
+		page := l.NewPage(l.PageOptionCache(missCache{}))
+		page.DOM().Body().Add(
+			l.T("h1", "Hydration"),
+			l.T("div", l.T("p", "Same"), l.T("p", "Render "+strconv.Itoa(n))),
+		)
+
+		return page
+	}))
+	t.Cleanup(ts.Close)
+
+	res, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match := hashRegexp.FindStringSubmatch(string(b))
+	if match == nil {
+		t.Fatalf("hash not rendered: %s", b)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(ts.URL, "http")+"/?hlive=1&hhash="+match[1], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	waitForMessage(t, conn, "y|q|")
+
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(string(msg), "y|r|"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(body), "Render 2") {
+		t.Fatalf("expected the new render: %s", body)
+	}
+
+	// What hlive.hydrationReport sends
+	report := "<h1>Hydration</h1><div><p>Same</p><p>Render 1</p></div>"
+	if err := conn.WriteJSON(map[string]any{"t": "h", "d": map[string]string{"b": report}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `selector="body > div:nth-child(2) > p:nth-child(2)"`
+
+	for deadline := time.Now().Add(time.Second); !strings.Contains(logs.String(), want); {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %s in: %s", want, logs.String())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPage_HydrationMatch(t *testing.T) {
+	t.Parallel()
+
+	s := l.NewPageServer(func() *l.Page {
+		page := l.NewPage(l.PageOptionCache(missCache{}))
+		page.DOM().Body().Add(l.T("p", "Hello"))
+
+		return page
+	})
+	// The token is part of the server side render
+	s.CSRFKey = []byte("test key")
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	res, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match := hashRegexp.FindStringSubmatch(string(b))
+	token := csrfRegexp.FindStringSubmatch(string(b))
+
+	if match == nil || token == nil || len(res.Cookies()) == 0 {
+		t.Fatalf("hash or token not rendered: %s", b)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(ts.URL, "http")+"/?hlive=1&hhash="+match[1]+"&hcsrf="+url.QueryEscape(token[1]),
+		http.Header{"Cookie": []string{res.Cookies()[0].Name + "=" + res.Cookies()[0].Value}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	if err := conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		if strings.HasPrefix(string(msg), "y|") {
+			t.Fatalf("unexpected message: %s", msg)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Package domapply applies hlive diffs to a DOM parsed with golang.org/x/net/html, the same way page.js does.
+package domapply
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var ErrTarget = errors.New("diff target not found")
+
+// The parts of a diff message: d|type|root|path|contentType|content
+const (
+	partType = iota + 1
+	partRoot
+	partPath
+	partContentType
+	partContent
+	parts
+)
+
+// Hooks for state a browser keeps outside the DOM
+type Hooks struct {
+	// Value is called instead of setting the value attribute
+	Value func(n *html.Node, value string)
+	// Removed is called after a node is removed
+	Removed func(n *html.Node)
+}
+
+// Message applies each diff in a message
+func Message(doc *html.Node, message string, hooks Hooks) error {
+	lines := strings.Split(message, "\n")
+	for i := 0; i < len(lines); i++ {
+		if lines[i] == "" {
+			continue
+		}
+
+		p := strings.Split(lines[i], "|")
+		if len(p) != parts || p[0] != "d" {
+			return fmt.Errorf("invalid diff message format: %s", lines[i])
+		}
+
+		if err := Diff(doc, p, hooks); err != nil {
+			return fmt.Errorf("%s: %w", lines[i], err)
+		}
+	}
+
+	return nil
+}
+
+// Diff applies a diff message split on "|", like hlive.processMsg
+func Diff(doc *html.Node, p []string, hooks Hooks) error {
+	if len(p) != parts {
+		return fmt.Errorf("invalid diff message format: %s", strings.Join(p, "|"))
+	}
+
+	target, err := FindTarget(doc, p)
+	if err != nil {
+		return err
+	}
+
+	b, err := base64.StdEncoding.DecodeString(p[partContent])
+	if err != nil {
+		return fmt.Errorf("base64 decode: %w", err)
+	}
+
+	content := string(b)
+	diffType := p[partType]
+	contentType := p[partContentType]
+
+	path := strings.Split(p[partPath], ">")
+	index, _ := strconv.Atoi(path[len(path)-1])
+
+	switch {
+	// Text
+	case contentType == "t" && diffType == "c":
+		insertAt(target, &html.Node{Type: html.TextNode, Data: content}, index)
+	case contentType == "t":
+		setTextContent(target, content)
+	// Tag / HTML
+	case contentType == "h" && diffType == "c":
+		node, err := ParseFragment(content)
+		if err != nil {
+			return err
+		}
+
+		if node != nil {
+			insertAt(target, node, index)
+		}
+	case contentType == "h" && diffType == "u":
+		node, err := ParseFragment(content)
+		if err != nil {
+			return err
+		}
+
+		if node != nil && target.Parent != nil {
+			target.Parent.InsertBefore(node, target)
+		}
+
+		remove(target, hooks)
+	// Attributes
+	case contentType == "a":
+		// We strictly control this Attribute data format
+		eq := strings.Index(content, "=")
+		if eq == -1 || len(content) < eq+2 {
+			return fmt.Errorf("invalid attribute: %s", content)
+		}
+
+		name := strings.TrimSpace(content[:eq])
+		value := content[eq+2 : len(content)-1]
+
+		switch {
+		case diffType == "d":
+			RemoveAttr(target, name)
+		case name == "value" && hooks.Value != nil:
+			hooks.Value(target, value)
+		default:
+			SetAttr(target, name, value)
+		}
+	// Generic delete
+	case diffType == "d":
+		remove(target, hooks)
+	}
+
+	return nil
+}
+
+// FindTarget is like hlive.findDiffTarget
+func FindTarget(doc *html.Node, p []string) (*html.Node, error) {
+	target := doc
+
+	if root := p[partRoot]; root != "doc" {
+		target = findHID(doc, root)
+		if target == nil {
+			return nil, fmt.Errorf("%w: root: %s", ErrTarget, root)
+		}
+	}
+
+	path := strings.Split(p[partPath], ">")
+	for j := 0; j < len(path); j++ {
+		// The node doesn't exist yet
+		if p[partType] == "c" && (p[partContentType] == "h" || p[partContentType] == "t") && j == len(path)-1 {
+			continue
+		}
+
+		// Happens when we start the path for a new component
+		if path[j] == "" {
+			continue
+		}
+
+		index, err := strconv.Atoi(path[j])
+		if err != nil {
+			return nil, fmt.Errorf("path: %w", err)
+		}
+
+		// Skip child nodes found above the head, often added by browser plugins
+		if target.Type == html.ElementNode && target.Data == "html" {
+			for child := target.FirstChild; child != nil && child.Data != "head"; child = child.NextSibling {
+				index++
+			}
+		}
+
+		child := childAt(target, index)
+		if child == nil {
+			return nil, fmt.Errorf("%w: child not found at section: %d", ErrTarget, j)
+		}
+
+		target = child
+	}
+
+	return target, nil
+}
+
+// ParseFragment is like setting innerHTML on a template element and taking the first child
+func ParseFragment(content string) (*html.Node, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(content), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "template",
+		DataAtom: atom.Template,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parse html: %w", err)
+	}
+
+	if len(nodes) == 0 {
+		return nil, nil
+	}
+
+	return nodes[0], nil
+}
+
+// SetAttr is like setAttribute
+func SetAttr(n *html.Node, name string, value string) {
+	for i := 0; i < len(n.Attr); i++ {
+		if n.Attr[i].Namespace == "" && n.Attr[i].Key == name {
+			n.Attr[i].Val = value
+
+			return
+		}
+	}
+
+	n.Attr = append(n.Attr, html.Attribute{Key: name, Val: value})
+}
+
+// RemoveAttr is like removeAttribute
+func RemoveAttr(n *html.Node, name string) {
+	for i := 0; i < len(n.Attr); i++ {
+		if n.Attr[i].Namespace == "" && n.Attr[i].Key == name {
+			n.Attr = append(n.Attr[:i], n.Attr[i+1:]...)
+
+			return
+		}
+	}
+}
+
+func remove(n *html.Node, hooks Hooks) {
+	if n.Parent != nil {
+		n.Parent.RemoveChild(n)
+	}
+
+	if hooks.Removed != nil {
+		hooks.Removed(n)
+	}
+}
+
+func findHID(n *html.Node, id string) *html.Node {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode {
+			for i := 0; i < len(child.Attr); i++ {
+				// hlive.AttrID
+				if child.Attr[i].Key == "hid" && child.Attr[i].Val == id {
+					return child
+				}
+			}
+		}
+
+		if found := findHID(child, id); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func childAt(n *html.Node, index int) *html.Node {
+	child := n.FirstChild
+	for i := 0; child != nil && i < index; i++ {
+		child = child.NextSibling
+	}
+
+	return child
+}
+
+func insertAt(parent *html.Node, node *html.Node, index int) {
+	if child := childAt(parent, index); child != nil {
+		parent.InsertBefore(node, child)
+	} else {
+		parent.AppendChild(node)
+	}
+}
+
+// Like setting textContent
+func setTextContent(n *html.Node, text string) {
+	if n.Type == html.TextNode {
+		n.Data = text
+
+		return
+	}
+
+	for n.FirstChild != nil {
+		n.RemoveChild(n.FirstChild)
+	}
+
+	if text != "" {
+		n.AppendChild(&html.Node{Type: html.TextNode, Data: text})
+	}
+}
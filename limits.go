@@ -0,0 +1,128 @@
+package hlive
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LimitViolation is the limit that was exceeded
+type LimitViolation string
+
+// Limit violations
+const (
+	LimitMessageSize LimitViolation = "message_size"
+	LimitFileSize    LimitViolation = "file_size"
+	LimitEventRate   LimitViolation = "event_rate"
+	LimitInFlight    LimitViolation = "in_flight"
+)
+
+// Limits for the messages a browser sends over a WebSocket connection, each session has its own.
+// Zero means no limit.
+type Limits struct {
+	// MaxMessageSize in bytes, for a single WebSocket message. The connection is closed if exceeded.
+	MaxMessageSize int64
+	// MaxFileSize in bytes, for a single uploaded file
+	MaxFileSize int64
+	// EventsPerSecond is the rate of messages, mostly events, allowed from the browser
+	EventsPerSecond float64
+	// EventsBurst is the number of messages allowed above the rate, defaults to EventsPerSecond
+	EventsBurst int
+	// MaxInFlight is the number of event handlers allowed to run at the same time
+	MaxInFlight int
+	// OnViolation is called each time a limit is exceeded. The message is dropped.
+	OnViolation func(ctx context.Context, sess *PageSession, violation LimitViolation)
+	// Disconnect the session when a limit is exceeded
+	Disconnect bool
+}
+
+// violation drops the message, calls the hook, and disconnects if needed
+func (sess *PageSession) violation(ctx context.Context, violation LimitViolation) {
+	sess.logger.Warn("limit exceeded", "violation", violation, "sess", sess.GetID())
+
+	if sess.limits.OnViolation != nil {
+		sess.limits.OnViolation(ctx, sess, violation)
+	}
+
+	if !sess.limits.Disconnect {
+		return
+	}
+
+	sess.GetInitialContextCancel()()
+
+	sess.muWrite.Lock()
+	if sess.wsConn != nil {
+		if err := sess.wsConn.Close(); err != nil {
+			sess.logger.Error("limit disconnect: ws conn close", "error", err, "sess", sess.id)
+		}
+	}
+	sess.muWrite.Unlock()
+}
+
+// inFlightAcquire returns false if the max number of event handlers are running
+func (sess *PageSession) inFlightAcquire() bool {
+	if sess.limits.MaxInFlight <= 0 {
+		return true
+	}
+
+	if atomic.AddInt32(&sess.inFlight, 1) > int32(sess.limits.MaxInFlight) {
+		atomic.AddInt32(&sess.inFlight, -1)
+
+		return false
+	}
+
+	return true
+}
+
+func (sess *PageSession) inFlightRelease() {
+	if sess.limits.MaxInFlight <= 0 {
+		return
+	}
+
+	atomic.AddInt32(&sess.inFlight, -1)
+}
+
+// tokenBucket rate limiter, it's safe for concurrent use
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+
+	b := float64(burst)
+	if burst <= 0 {
+		b = math.Max(1, math.Ceil(rate))
+	}
+
+	return &tokenBucket{rate: rate, burst: b, tokens: b, last: time.Now()}
+}
+
+// allow takes a token if there is one. A nil bucket has no limit.
+func (b *tokenBucket) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
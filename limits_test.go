@@ -0,0 +1,118 @@
+package hlive_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/go-test/deep"
+	"github.com/gorilla/websocket"
+)
+
+func newLimitsPageServer(limits l.Limits, elements ...any) (*l.PageServer, <-chan l.LimitViolation, <-chan struct{}) {
+	violations := make(chan l.LimitViolation, 10)
+	mounted := make(chan struct{})
+
+	limits.OnViolation = func(_ context.Context, _ *l.PageSession, violation l.LimitViolation) {
+		violations <- violation
+	}
+
+	s := l.NewPageServer(func() *l.Page {
+		page := l.NewPage()
+		page.DOM().Body().Add(elements...)
+		page.HookMountAdd(func(_ context.Context, _ *l.Page) {
+			close(mounted)
+		})
+
+		return page
+	})
+	s.Limits = limits
+
+	return s, violations, mounted
+}
+
+func waitForViolation(t *testing.T, violations <-chan l.LimitViolation, want l.LimitViolation) {
+	t.Helper()
+
+	select {
+	case got := <-violations:
+		if diff := deep.Equal(want, got); diff != nil {
+			t.Error(diff)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no violation")
+	}
+}
+
+func TestLimits_EventRate(t *testing.T) {
+	t.Parallel()
+
+	s, violations, _ := newLimitsPageServer(l.Limits{EventsPerSecond: 1, EventsBurst: 1})
+	conn := dialPageServer(t, s)
+
+	for i := 0; i < 3; i++ {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"t":"l","d":{"m":"hi"}}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitForViolation(t, violations, l.LimitEventRate)
+}
+
+func TestLimits_MessageSizeDisconnect(t *testing.T) {
+	t.Parallel()
+
+	s, violations, _ := newLimitsPageServer(l.Limits{MaxMessageSize: 64, Disconnect: true})
+	conn := dialPageServer(t, s)
+
+	msg := `{"t":"l","d":{"m":"` + strings.Repeat("a", 1024) + `"}}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForViolation(t, violations, l.LimitMessageSize)
+
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if strings.Contains(err.Error(), "timeout") {
+				t.Error("connection not closed")
+			}
+
+			break
+		}
+	}
+}
+
+func TestLimits_InFlight(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	binding := l.On("click", func(_ context.Context, _ l.Event) {
+		<-release
+	})
+
+	s, violations, mounted := newLimitsPageServer(l.Limits{MaxInFlight: 1}, l.C("button", binding))
+	conn := dialPageServer(t, s)
+
+	select {
+	case <-mounted:
+	case <-time.After(time.Second):
+		t.Fatal("page not mounted")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"t":"e","i":"`+binding.ID+`"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitForViolation(t, violations, l.LimitInFlight)
+}
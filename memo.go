@@ -0,0 +1,35 @@
+package hlive
+
+const memoKeyStatic = "static"
+
+// Memo marks a Tag or Component subtree as memoised by key.
+//
+// After the first render, the pipeline reuses the walked subtree and the Differ skips it, until the key changes.
+// Changes made inside a memoised subtree are not rendered until you add a Memo with a new key.
+func Memo(key string) *Attribute {
+	return NewAttribute(AttrMemo, key)
+}
+
+// Static marks a Tag or Component subtree as static. It will only be walked and diffed once.
+//
+// Static is a Memo with a key that never changes.
+func Static() *Attribute {
+	return Memo(memoKeyStatic)
+}
+
+// memoKey returns the memo key for a Tagger or an empty string if it's not memoised
+func memoKey(tagger Tagger) string {
+	attrs := tagger.GetAttributes()
+	for i := 0; i < len(attrs); i++ {
+		if attrs[i].GetName() == AttrMemo {
+			return attrs[i].GetValue()
+		}
+	}
+
+	return ""
+}
+
+type pipelineMemo struct {
+	key string
+	tag *Tag
+}
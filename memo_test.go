@@ -0,0 +1,160 @@
+package hlive_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/go-test/deep"
+)
+
+func TestMemo_PipelineWalksOnce(t *testing.T) {
+	t.Parallel()
+
+	var walks int
+
+	nav := l.T("nav", l.Memo("v1"), l.T("a", "Home"))
+
+	page := l.NewPage()
+	page.DOM().Body().Add(nav)
+
+	pp := l.NewPipelineProcessor("test_count")
+	pp.BeforeTagger = func(_ context.Context, _ io.Writer, tag l.Tagger) (l.Tagger, error) {
+		if tag.GetName() == "a" {
+			walks++
+		}
+
+		return tag, nil
+	}
+	page.PipelineDiff().Add(pp)
+
+	for i := 0; i < 2; i++ {
+		if _, err := page.RunDiffPipeline(context.Background(), io.Discard); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if diff := deep.Equal(1, walks); diff != nil {
+		t.Error(diff)
+	}
+
+	nav.Add(l.Memo("v2"))
+
+	if _, err := page.RunDiffPipeline(context.Background(), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(2, walks); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestMemo_DifferSkipsUnchangedKey(t *testing.T) {
+	t.Parallel()
+
+	differ := l.NewDiffer()
+
+	static := l.T("div", l.Static(), "old")
+
+	diffs, err := differ.Trees("doc", "", static, static)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diffs) != 0 {
+		t.Errorf("expected no diffs, got %d", len(diffs))
+	}
+
+	// Different Taggers with the same id and key, like the server side render and the WebSocket render
+	comp := l.C("div", l.Memo("v1"), "old")
+	compNew := l.C("div", l.Memo("v1"), "new")
+	comp.SetID("1")
+	compNew.SetID("1")
+
+	diffs, err = differ.Trees("doc", "", comp, compNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diffs) != 1 {
+		t.Errorf("expected 1 diff for a different Tagger, got %d", len(diffs))
+	}
+
+	diffs, err = differ.Trees("doc", "", l.T("div", l.Memo("v1"), "old"), l.T("div", l.Memo("v2"), "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The memo attribute isn't sent
+	if len(diffs) != 1 {
+		t.Errorf("expected 1 diff, got %d", len(diffs))
+	}
+}
+
+func TestMemo_DifferReplacedStatic(t *testing.T) {
+	t.Parallel()
+
+	diffs, err := l.NewDiffer().Trees("doc", "",
+		l.T("div", l.Static(), "old"), l.T("div", l.Static(), "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diffs) != 1 {
+		t.Errorf("expected 1 diff, got %d", len(diffs))
+	}
+}
+
+func TestMemo_PipelineReplacedStatic(t *testing.T) {
+	t.Parallel()
+
+	box := l.Box(l.T("p", l.Static(), "old"))
+
+	page := l.NewPage()
+	page.DOM().Body().Add(l.T("div", box))
+
+	runDiff := func(old *l.NodeGroup) (*l.NodeGroup, int) {
+		t.Helper()
+
+		tree, err := page.RunDiffPipeline(context.Background(), io.Discard)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		diffs, err := l.NewDiffer().Trees("doc", "", old, tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return tree, len(diffs)
+	}
+
+	tree, _ := runDiff(l.G())
+
+	// Memo hit
+	tree, n := runDiff(tree)
+	if diff := deep.Equal(0, n); diff != nil {
+		t.Error(diff)
+	}
+
+	box.Set(l.T("p", l.Static(), "new"))
+
+	if _, n = runDiff(tree); n != 1 {
+		t.Errorf("expected 1 diff, got %d", n)
+	}
+}
+
+func TestMemo_NotRendered(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer(nil)
+	if err := l.NewRenderer().HTML(buf, l.T("div", l.Memo("v1"), l.Static(), "Hi")); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal("<div>Hi</div>", buf.String()); diff != nil {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,35 @@
+package hlive
+
+import (
+	"context"
+	"encoding/base64"
+)
+
+const ctxNavigate CtxKey = "navigate"
+
+// navigator hands the connection over to the Page for the URL, it returns false when there is no Page for it
+type navigator func(ctx context.Context, target string) bool
+
+// Nav makes the browser navigate to links inside this Tag without reloading the page.
+//
+// The WebSocket connection is kept open and handed over to the Page for the new URL. Only the differences are
+// sent to the browser. Requires a PageServer with Navigate set, else the browser does a normal page load.
+func Nav() *Attribute {
+	return NewAttribute(AttrNav, "")
+}
+
+func (p *Page) processMsgNavigate(ctx context.Context, msg websocketMessage) {
+	target := msg.Data["u"]
+	if target == "" {
+		p.logger.Error("navigate: missing url")
+
+		return
+	}
+
+	if nav, ok := ctx.Value(ctxNavigate).(navigator); ok && nav(ctx, target) {
+		return
+	}
+
+	// No Page to hand over to, let the browser load it
+	p.wsSend(ctx, "n|l|"+base64.StdEncoding.EncodeToString([]byte(target)))
+}
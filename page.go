@@ -9,9 +9,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"log/slog"
 )
@@ -33,6 +36,10 @@ type Page struct {
 	dom DOM
 	// What we think is the browser DOM is
 	domBrowser any
+	// The hash of the server side render the browser has, checked when we can't use the cache
+	hydrationHash string
+	// Our body when the browser's didn't match, to compare with what the browser sends back
+	hydrationBody string
 	// Lock the page for writes
 	mu sync.RWMutex
 	// sessID is the WebSocket connection session id
@@ -40,12 +47,39 @@ type Page struct {
 	sessID string
 	// Component caches, to prevent walking to tree to find something
 	eventBindings *sync.Map
+	// Provided values for each event binding
+	eventProviders *sync.Map
+	// Provided values from above each Component, for RenderComponent
+	componentProviders *sync.Map
 	// Channel of outbound messages.
 	send chan<- MessageWS
 	// Channel of inbound messages.
 	receive <-chan MessageWS
+	// The connection was handed to the next Page, see detach
+	detached atomic.Bool
+	// Messages the next Page should handle, received after the connection was handed over
+	pendingWS []MessageWS
 	// cache async safe
 	cache Cache
+	// How long to cache server side renders, for a CacheAdvanced
+	cacheTTL time.Duration
+	// The server side render is unique, only the next WebSocket connection will use it
+	cacheConnectOnly bool
+	// The URL the browser is showing
+	url *url.URL
+	// Content-Security-Policy nonce for script tags
+	scriptNonce string
+	// Script bundle, from assets
+	assets  *Assets
+	scripts []string
+	// Hashes of inline scripts, so they are only added once
+	scriptsInline map[string]struct{}
+	// Lock for url, scriptNonce and scripts, they can be used during a render
+	muMeta sync.RWMutex
+	// Only call handlers for events that match the browser DOM
+	strictEvents bool
+	// Stream the server side render
+	streaming bool
 	//
 	// Hooks
 	//
@@ -62,12 +96,16 @@ type Page struct {
 	hookMount []func(context.Context, *Page)
 	// When we close the page
 	hookUnmount []func(context.Context, *Page)
+	// When strict events rejects an event
+	hookEventReject []func(context.Context, Event, error)
 }
 
 func NewPage(options ...PageOption) *Page {
 	p := &Page{
-		dom:    NewDOM(),
-		logger: slog.New(slog.DiscardHandler),
+		dom:                NewDOM(),
+		logger:             slog.New(slog.DiscardHandler),
+		eventProviders:     &sync.Map{},
+		componentProviders: &sync.Map{},
 	}
 
 	for i := 0; i < len(options); i++ {
@@ -82,9 +120,13 @@ func NewPage(options ...PageOption) *Page {
 		p.renderer = NewRenderer()
 	}
 
+	if p.assets != nil {
+		p.dom.head.Add(scriptBundle{page: p})
+	}
+
 	if p.differ == nil {
 		p.differ = NewDiffer()
-		p.dom.head.Add(T("script", HTML(p.differ.JavaScript)))
+		p.ScriptAdd(p.differ.JavaScript)
 	}
 
 	// Differ Pipeline
@@ -93,8 +135,10 @@ func NewPage(options ...PageOption) *Page {
 			PipelineProcessorAttributePluginMount(p),
 			PipelineProcessorMount(),
 			PipelineProcessorEventBindingCache(p.eventBindings),
+			PipelineProcessorProviders(p),
 			PipelineProcessorUnmount(p),
 			PipelineProcessorConvertToString(),
+			PipelineProcessorScriptNonce(p),
 		)
 	}
 	// Server Side Render Pipeline
@@ -102,11 +146,12 @@ func NewPage(options ...PageOption) *Page {
 		p.pipelineSSR = NewPipeline(
 			PipelineProcessorAttributePluginMountSSR(p),
 			PipelineProcessorConvertToString(),
+			PipelineProcessorScriptNonce(p),
 		)
 	}
 
 	if p.cache != nil {
-		p.pipelineSSR.Add(PipelineProcessorRenderHashAndCache(p.logger, p.renderer, p.cache))
+		p.pipelineSSR.Add(pipelineProcessorRenderHashAndCache(p.logger, p.renderer, p.cacheSSRSet))
 		p.DOM().HTML().Add(Attrs{PageHashAttr: PageHashAttrTmpl})
 	} else {
 		p.pipelineSSR.Add(PipelineProcessorRenderer(p.renderer))
@@ -154,7 +199,16 @@ func (p *Page) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (p *Page) serverHTTP(w http.ResponseWriter, r *http.Request) error {
-	_, err := p.runRenderPipeline(r.Context(), w)
+	p.setURL(r.URL)
+
+	ctx := context.WithValue(r.Context(), CtxPage, p)
+	ctx = context.WithValue(ctx, CtxRequest, r)
+
+	if p.streaming {
+		return p.streamHTTP(ctx, w)
+	}
+
+	_, err := p.runRenderPipeline(ctx, w)
 
 	return err
 }
@@ -167,6 +221,8 @@ func (p *Page) RunRenderPipeline(ctx context.Context, w io.Writer) (*NodeGroup,
 }
 
 func (p *Page) runRenderPipeline(ctx context.Context, w io.Writer) (*NodeGroup, error) {
+	ctx = context.WithValue(ctx, ctxSSR, true)
+
 start:
 	tree, err := p.pipelineSSR.run(ctx, w, p.getNodes())
 
@@ -201,6 +257,12 @@ func (p *Page) ServeWS(ctx context.Context, sessID string, send chan<- MessageWS
 	var err error
 	p.mu.Lock()
 
+	ctx = context.WithValue(ctx, CtxPage, p)
+
+	if r, ok := RequestFromContext(ctx); ok {
+		p.setURL(r.URL)
+	}
+
 	if p.send == nil {
 		p.send = send
 	}
@@ -217,15 +279,18 @@ func (p *Page) ServeWS(ctx context.Context, sessID string, send chan<- MessageWS
 	if p.domBrowser == nil {
 		p.logger.Debug("ServeWS: browser render")
 		// We need a static render
-		p.domBrowser, err = p.runRenderPipeline(ctx, io.Discard)
+		var tree *NodeGroup
+		tree, err = p.runRenderPipeline(ctx, io.Discard)
 		if err != nil {
 			p.logger.Error("ServeWS: render pipeline", "error", err)
 		}
+
+		p.domBrowser = tree
+		p.checkHydration(ctx, tree)
 	}
 
 	// Add render function to context
-	ctx = context.WithValue(ctx, CtxRender, p.executeRenderWS)
-	ctx = context.WithValue(ctx, CtxRenderComponent, p.renderComponentWS)
+	ctx = p.contextWS(ctx)
 
 	p.mu.Unlock()
 
@@ -260,6 +325,15 @@ func (p *Page) ServeWS(ctx context.Context, sessID string, send chan<- MessageWS
 		}
 	}()
 
+	p.mu.Lock()
+	pending := p.pendingWS
+	p.pendingWS = nil
+	p.mu.Unlock()
+
+	for i := 0; i < len(pending); i++ {
+		p.serveMessageWS(ctx, sessID, taskQueue, pending[i])
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -269,64 +343,134 @@ func (p *Page) ServeWS(ctx context.Context, sessID string, send chan<- MessageWS
 				return nil
 			}
 
-			// We can't block here else we can't close and events here can trigger a close
-			go func() {
-				f := func() {
-					message := messageWS.Message
-					msg := websocketMessage{Data: map[string]string{}}
+			// Navigated away, keep it for the next Page
+			if ctx.Err() != nil {
+				p.mu.Lock()
+				p.pendingWS = append(p.pendingWS, messageWS)
+				p.mu.Unlock()
 
-					if messageWS.IsBinary {
-						msgParts := bytes.SplitN(message, []byte("\n\n"), 2)
+				return nil
+			}
 
-						if len(msgParts) != 2 {
-							p.logger.Error("invalid binary message")
+			p.serveMessageWS(ctx, sessID, taskQueue, messageWS)
+		}
+	}
+}
 
-							return
-						}
+func (p *Page) serveMessageWS(ctx context.Context, sessID string, taskQueue chan<- func(), messageWS MessageWS) {
+	// We can't block here else we can't close and events here can trigger a close
+	go func() {
+		f := func() {
+			message := messageWS.Message
+			msg := websocketMessage{Data: map[string]string{}}
 
-						message = msgParts[0]
-						msg.fileData = msgParts[1]
-					}
+			if messageWS.IsBinary {
+				msgParts := bytes.SplitN(message, []byte("\n\n"), 2)
+
+				if len(msgParts) != 2 {
+					p.logger.Error("invalid binary message")
+
+					return
+				}
 
-					p.logger.Log(ctx, LevelTrace, "ws msg recv", "msg", string(message))
+				message = msgParts[0]
+				msg.fileData = msgParts[1]
+			}
+
+			p.logger.Log(ctx, LevelTrace, "ws msg recv", "msg", string(message))
+
+			if err := json.Unmarshal(message, &msg); err != nil {
+				p.logger.Error("ws msg unmarshal", "error", err, "json", string(message))
 
-					if err := json.Unmarshal(message, &msg); err != nil {
-						p.logger.Error("ws msg unmarshal", "error", err, "json", string(message))
+				return
+			}
+
+			switch msg.Typ {
+			// log
+			case "l":
+				p.logger.Info("ws log", "log", msg.Data["m"], "sess", sessID)
+			// Event
+			case "e":
+				sess, hasSess := SessionFromContext(ctx)
+
+				if len(msg.fileData) != 0 && msg.File != nil {
+					if hasSess && sess.limits.MaxFileSize > 0 && int64(len(msg.fileData)) > sess.limits.MaxFileSize {
+						sess.violation(ctx, LimitFileSize)
 
 						return
 					}
 
-					switch msg.Typ {
-					// log
-					case "l":
-						p.logger.Info("ws log", "log", msg.Data["m"], "sess", sessID)
-					// Event
-					case "e":
-						if len(msg.fileData) != 0 && msg.File != nil {
-							msg.File.Data = msg.fileData
-						}
-
-						// Call handler
-						go p.processMsgEvent(ctx, msg)
-					default:
-						p.logger.Error("ws msg recv: unexpected message format", "msg", string(message))
-					}
+					msg.File.Data = msg.fileData
 				}
 
-				select {
-				case <-ctx.Done():
+				if hasSess && !sess.inFlightAcquire() {
+					sess.violation(ctx, LimitInFlight)
+
 					return
-				case taskQueue <- f:
 				}
-			}()
+
+				// Call handler
+				go func() {
+					if hasSess {
+						defer sess.inFlightRelease()
+					}
+
+					p.processMsgEvent(ctx, msg)
+				}()
+			// Navigate
+			case "n":
+				p.processMsgNavigate(ctx, msg)
+			// Resync
+			case "y":
+				p.processMsgResync(ctx, msg)
+			// Hydration
+			case "h":
+				p.processMsgHydration(msg)
+			default:
+				p.logger.Error("ws msg recv: unexpected message format", "msg", string(message))
+			}
 		}
-	}
+
+		select {
+		case <-ctx.Done():
+			return
+		case taskQueue <- f:
+		}
+	}()
+}
+
+// detach hands the connection to the next Page, the Page stops sending and its messages go to next
+func (p *Page) detach(next *Page) {
+	// Wait for renders that are running
+	p.mu.Lock()
+	p.detached.Store(true)
+	domBrowser := p.domBrowser
+	pending := p.pendingWS
+	p.domBrowser, p.pendingWS = nil, nil
+	p.mu.Unlock()
+
+	next.mu.Lock()
+	next.domBrowser = domBrowser
+	next.pendingWS = append(pending, next.pendingWS...)
+	next.mu.Unlock()
+}
+
+// The context a connected Page gives to handlers, so Render works
+func (p *Page) contextWS(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, CtxPage, p)
+	ctx = context.WithValue(ctx, CtxRender, p.executeRenderWS)
+
+	return context.WithValue(ctx, CtxRenderComponent, p.renderComponentWS)
 }
 
 func (p *Page) executeRenderWS(ctx context.Context) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if p.detached.Load() {
+		return
+	}
+
 	// Do a dynamic render
 	diffs, err := p.renderWS(ctx)
 	if err != nil {
@@ -344,6 +488,11 @@ func (p *Page) executeRenderWS(ctx context.Context) {
 }
 
 func (p *Page) wsSend(ctx context.Context, message string) {
+	// The connection belongs to the next Page
+	if ctx.Err() != nil || p.detached.Load() {
+		return
+	}
+
 	p.logger.Log(ctx, LevelTrace, "ws send", "msg", message)
 
 	select {
@@ -380,9 +529,8 @@ func (p *Page) diffsToMsg(diffs []Diff) string {
 		} else if diff.Attribute != nil {
 			message += "a|"
 
-			if err := p.renderer.Attribute([]Attributer{diff.Attribute}, bb); err != nil {
-				p.logger.Error("diffs to msg: render attribute", "error", err)
-			}
+			// Not escaped, the browser uses setAttribute
+			bb.WriteString(" " + diff.Attribute.GetName() + `="` + diff.Attribute.GetValue() + `"`)
 		} else if diff.Tag != nil {
 			el = diff.Tag
 			message += "h|"
@@ -409,6 +557,7 @@ func (p *Page) processMsgEvent(ctx context.Context, msg websocketMessage) {
 	isInitial, _ := strconv.ParseBool(msg.Data["init"])
 
 	e := Event{
+		Type:      msg.Data["type"],
 		Value:     msg.Data["value"],
 		Values:    msg.ValueMulti,
 		IsInitial: isInitial,
@@ -423,6 +572,11 @@ func (p *Page) processMsgEvent(ctx context.Context, msg websocketMessage) {
 		Extra:     msg.Extra,
 	}
 
+	// The URL changed in the browser, e.g. popstate
+	if u, ok := msg.Data["url"]; ok {
+		p.updateURL(u)
+	}
+
 	ids := strings.Split(msg.ID, ",")
 	for i := 0; i < len(ids); i++ {
 		id := ids[i]
@@ -441,10 +595,23 @@ func (p *Page) processMsgEvent(ctx context.Context, msg websocketMessage) {
 				p.logger.Error("binding handler nil", "id", id)
 
 				p.eventBindings.Delete(id)
+				p.eventProviders.Delete(id)
 
 				break
 			}
 
+			if p.strictEvents {
+				if err := p.validateEvent(e); err != nil {
+					p.rejectEvent(ctx, e, err)
+
+					continue
+				}
+			}
+
+			// Values from Providers above the bound Component
+			scope, _ := p.eventProviders.Load(id)
+			ctx = context.WithValue(ctx, ctxKeyProviders, scope)
+
 			// Hook
 			for j := 0; j < len(p.hookBeforeEvent); j++ {
 				ctx, e = p.hookBeforeEvent[j](ctx, e)
@@ -460,6 +627,7 @@ func (p *Page) processMsgEvent(ctx context.Context, msg websocketMessage) {
 			// Once, do this after calling the handler so the developer can change their mind
 			if e.Binding.Once {
 				p.eventBindings.Delete(id)
+				p.eventProviders.Delete(id)
 				binding.Component.RemoveEventBinding(id)
 			}
 
@@ -511,6 +679,10 @@ func (p *Page) renderComponentWS(ctx context.Context, comp Componenter) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if p.detached.Load() {
+		return
+	}
+
 	oldTag := p.findComponentInTree(comp.GetID())
 	if oldTag == nil {
 		p.logger.Error("render component ws: can't find component in tree", "id", comp.GetID(), "name", comp.GetName())
@@ -518,6 +690,10 @@ func (p *Page) renderComponentWS(ctx context.Context, comp Componenter) {
 		return
 	}
 
+	// The values provided above the Component when it was last rendered in the tree, not what ctx has
+	scope, _ := p.componentProviders.Load(comp.GetID())
+	ctx = context.WithValue(ctx, ctxKeyProviders, scope)
+
 	// TODO: replace discard
 	newTreeNode, err := p.pipelineDiff.runNode(ctx, io.Discard, comp)
 	if err != nil {
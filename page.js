@@ -5,6 +5,10 @@ let hlive = {
     conn: null,
     initSyncDone: false,
     sessID: 1,
+    pagePath: window.location.pathname,
+    csrf: null,
+    // Content-Security-Policy nonce, only available while the script first runs
+    nonce: document.currentScript && document.currentScript.nonce ? document.currentScript.nonce : "",
 
     afterMessage: new Map(),
     beforeRemoveEventHandlers: new Map(),
@@ -82,6 +86,10 @@ hlive.eventHandlerHelper = (e, handlerID, isInitial) => {
     };
 
     let d = {};
+    if (e.type !== undefined) {
+        d.type = e.type;
+    }
+
     if (el.value !== undefined) {
         d.value = String(el.value);
         if (isInitial) {
@@ -239,7 +247,7 @@ hlive.initSync = () => {
 
             if (name === "keyup" || name === "keydown" || name === "keypress" || name === "input" || name === "change") {
                 const evt = {
-                    currentTarget: el
+                    currentTarget: el, type: name,
                 }
 
                 hlive.eventHandlerHelper(evt, parts[0], true);
@@ -273,7 +281,7 @@ hlive.postMessage = () => {
             };
 
             let msg = {
-                t: "e", file: fileMeta,
+                t: "e", file: fileMeta, d: {type: "upload"},
             };
 
             queueMicrotask(function () {
@@ -363,6 +371,45 @@ hlive.findDiffTarget = (diff) => {
     return target;
 }
 
+// Script bundles, the file name is the hashes of the scripts in the bundle
+hlive.bundleHashes = (src) => {
+    const name = src.substring(src.lastIndexOf("/") + 1).replace(/\.js$/, "");
+
+    return name === "" ? [] : name.split("-");
+}
+
+// Changing a script's src doesn't run it, so load a bundle of the new scripts
+hlive.loadBundle = (oldSrc, newSrc) => {
+    const loaded = hlive.bundleHashes(oldSrc || "");
+    const hashes = hlive.bundleHashes(newSrc).filter(function (hash) {
+        return !loaded.includes(hash);
+    });
+
+    if (hashes.length === 0) {
+        return;
+    }
+
+    const afterMessageBefore = new Set(hlive.afterMessage.keys());
+
+    let el = document.createElement("script");
+    el.src = newSrc.substring(0, newSrc.lastIndexOf("/") + 1) + hashes.join("-") + ".js";
+
+    if (hlive.nonce !== "") {
+        el.nonce = hlive.nonce;
+    }
+
+    // The new scripts missed the current message
+    el.onload = function () {
+        hlive.afterMessage.forEach(function (fn, key) {
+            if (!afterMessageBefore.has(key)) {
+                fn();
+            }
+        });
+    }
+
+    document.head.appendChild(el);
+}
+
 hlive.processMsg = (evt) => {
     let messages = evt.data.split('\n');
 
@@ -392,7 +439,10 @@ hlive.processMsg = (evt) => {
 
             const target = hlive.findDiffTarget(messages[i]);
 
+            // The DOM isn't what the server thinks it is, ask for a new body
             if (target === null) {
+                hlive.resync();
+
                 return;
             }
 
@@ -456,6 +506,10 @@ hlive.processMsg = (evt) => {
                             target.value = attrValue;
                         }
                     } else {
+                        if (attrName === "src" && target.hasAttribute("data-hlive-bundle")) {
+                            hlive.loadBundle(target.getAttribute("src"), attrValue);
+                        }
+
                         target.setAttribute(attrName, attrValue);
                     }
                 } else if (parts[hlive.diffParts.DiffType] === "d") {
@@ -471,18 +525,207 @@ hlive.processMsg = (evt) => {
                 hlive.removeEventHandlers(target);
                 target.remove();
             }
+            // Resync
+        } else if (parts[hlive.msgPart.Type] === "y") {
+            if (parts.length === 3 && parts[1] === "r") {
+                hlive.replaceBody(hlive.base64Decode(parts[2]));
+            } else if (parts.length === 3 && parts[1] === "q") {
+                hlive.hydrationReport();
+            }
             // Sessions
         } else if (parts[hlive.msgPart.Type] === "s") {
             if (parts.length === 3) {
                 hlive.sessID = parts[2];
             }
+            // Navigation
+        } else if (parts[hlive.msgPart.Type] === "n") {
+            if (parts.length !== 3) {
+                hlive.log("invalid navigation message format");
+                continue;
+            }
+
+            const href = hlive.base64Decode(parts[2]);
+
+            if (parts[1] === "l") {
+                // No live navigation for this URL, do a normal page load
+                window.location.assign(href);
+            } else if (parts[1] === "p") {
+                hlive.historyMark();
+                history.pushState({hlive: true}, "", href);
+                hlive.pagePath = window.location.pathname;
+            } else if (parts[1] === "r") {
+                history.replaceState({hlive: true}, "", href);
+                hlive.pagePath = window.location.pathname;
+            }
         }
     }
 }
 
+// Live navigation
+// Links inside an element with the data-hlive-nav attribute keep the WebSocket connection open, the server sends the
+// differences to the new page.
+hlive.navClickHandler = (e) => {
+    if (e.defaultPrevented || e.button !== 0 || e.metaKey || e.ctrlKey || e.shiftKey || e.altKey) {
+        return;
+    }
+
+    if (!e.target.closest) {
+        return;
+    }
+
+    const a = e.target.closest("a[href]");
+    if (!a || !a.closest("[data-hlive-nav]") || a.hasAttribute("download")) {
+        return;
+    }
+
+    if (a.target && a.target !== "_self") {
+        return;
+    }
+
+    const url = new URL(a.href, window.location.href);
+    if (url.origin !== window.location.origin) {
+        return;
+    }
+
+    // Let the browser handle in page anchors
+    if (url.hash !== "" && url.pathname === window.location.pathname && url.search === window.location.search) {
+        return;
+    }
+
+    e.preventDefault();
+
+    hlive.navigate(url.href);
+}
+
+hlive.navigate = (href) => {
+    if (!hlive.conn || hlive.conn.readyState !== 1) {
+        window.location.assign(href);
+
+        return;
+    }
+
+    hlive.historyMark();
+    history.pushState({hlive: true}, "", href);
+    hlive.pagePath = window.location.pathname;
+    hlive.sendNav();
+    window.scrollTo(0, 0);
+}
+
+// Mark the current entry so we know to handle going back to it
+hlive.historyMark = () => {
+    if (!history.state || !history.state.hlive) {
+        history.replaceState({hlive: true}, "");
+    }
+}
+
+hlive.sendNav = () => {
+    hlive.sendMsg({
+        t: "n", d: {u: window.location.pathname + window.location.search},
+    });
+}
+
+hlive.onpopstate = (e) => {
+    if (!e.state || !e.state.hlive) {
+        return;
+    }
+
+    if (!hlive.conn || hlive.conn.readyState !== 1) {
+        window.location.reload();
+
+        return;
+    }
+
+    // Same page, let the page's popstate bindings handle it
+    const bindings = document.querySelectorAll("[hon*='|popstate']");
+    if (window.location.pathname === hlive.pagePath && bindings.length !== 0) {
+        let ids = [];
+        bindings.forEach(function (el) {
+            ids = ids.concat(hlive.getEventHandlerIDs(el)["popstate"] || []);
+        });
+
+        hlive.sendMsg({
+            t: "e", i: ids.join(","), d: {
+                type: "popstate",
+                value: window.location.href,
+                url: window.location.pathname + window.location.search + window.location.hash,
+            },
+        });
+
+        return;
+    }
+
+    hlive.pagePath = window.location.pathname;
+    hlive.sendNav();
+}
+
+// Drift detection
+// Tag names and text, like the server's domSerialize
+hlive.domSerialize = (node) => {
+    let s = "";
+
+    for (let i = 0; i < node.childNodes.length; i++) {
+        const child = node.childNodes[i];
+
+        if (child.nodeType === Node.ELEMENT_NODE) {
+            s += "<" + child.localName + ">" + hlive.domSerialize(child) + "</>";
+        } else if (child.nodeType === Node.TEXT_NODE) {
+            s += child.data;
+        }
+    }
+
+    return s;
+}
+
+// FNV-1a of the body
+hlive.domChecksum = () => {
+    const bytes = new TextEncoder().encode(hlive.domSerialize(document.body));
+
+    let h = 0x811c9dc5;
+    for (let i = 0; i < bytes.length; i++) {
+        h ^= bytes[i];
+        h = Math.imul(h, 0x01000193);
+    }
+
+    return (h >>> 0).toString(16);
+}
+
+// The server sends a new body if ours doesn't match
+hlive.driftCheck = () => {
+    hlive.sendMsg({t: "y", d: {c: hlive.domChecksum()}});
+}
+
+// Ask for a new body
+hlive.resync = () => {
+    hlive.sendMsg({t: "y", d: {c: ""}});
+}
+
+// Our server side render didn't match, send it so the server can say where
+hlive.hydrationReport = () => {
+    hlive.sendMsg({t: "h", d: {b: document.body.innerHTML.substring(0, hlive.hydrationReportMax)}});
+}
+
+hlive.hydrationReportMax = 16384;
+
+hlive.replaceBody = (html) => {
+    const doc = new DOMParser().parseFromString("<!doctype html><html><head></head>" + html + "</html>", "text/html");
+
+    document.querySelectorAll("[hon]").forEach(function (el) {
+        hlive.removeEventHandlers(el);
+    });
+
+    document.body.replaceWith(document.adoptNode(doc.body));
+}
+
+hlive.driftTimer = null;
+
 hlive.onopen = (evt) => {
     hlive.log("con: open");
     hlive.reconnectCount = 0;
+
+    const interval = parseInt(document.documentElement.getAttribute("data-hlive-drift"), 10);
+    if (interval > 0 && hlive.driftTimer === null) {
+        hlive.driftTimer = setInterval(hlive.driftCheck, interval);
+    }
 }
 
 hlive.onmessage = (evt) => {
@@ -534,6 +777,19 @@ hlive.connect = () => {
         q += "hhash=" + hhash;
     }
 
+    // The token from the server side render, it's bound to this browser so each connection can use it
+    if (hlive.csrf === null) {
+        hlive.csrf = document.documentElement.getAttribute("data-hlive-csrf");
+    }
+
+    if (hlive.csrf !== null) {
+        q += "&hcsrf=" + encodeURIComponent(hlive.csrf);
+    }
+
+    if (hlive.nonce !== "") {
+        q += "&hnonce=" + encodeURIComponent(hlive.nonce);
+    }
+
     hlive.conn = new WebSocket(ws + "://" + window.location.host + window.location.pathname + q);
     hlive.conn.onopen = hlive.onopen;
     hlive.conn.onmessage = hlive.onmessage;
@@ -568,7 +824,30 @@ hlive.connectWails3 = () => {
     window.wails.Events.Emit({"name": "connect", "data":true, "sender": hlive.sessID.toString()});
 }
 
+document.addEventListener("click", hlive.navClickHandler);
+window.addEventListener("popstate", hlive.onpopstate);
+
+// Streamed pages can have head nodes that were added after the head was sent
+hlive.lateHead = () => {
+    document.querySelectorAll("template[data-hlive-late-head]").forEach(function (template) {
+        const bundle = document.head.querySelector("script[data-hlive-bundle]");
+        const lateBundle = template.content.querySelector("script[data-hlive-bundle]");
+
+        if (bundle && lateBundle) {
+            hlive.loadBundle(bundle.getAttribute("src"), lateBundle.getAttribute("src"));
+            bundle.setAttribute("src", lateBundle.getAttribute("src"));
+            lateBundle.remove();
+        }
+
+        exJS(template.content);
+        document.head.appendChild(template.content);
+        template.remove();
+    });
+}
+
 document.addEventListener("DOMContentLoaded", function (evt) {
+    hlive.lateHead();
+
     if (window.runtime !== undefined) {
         hlive.connectWails2()
     } else if (window.wails !== undefined) {
@@ -592,6 +871,10 @@ const exJS = (containerElement) => {
             clone.setAttribute(attr.name, attr.value);
         });
 
+        if (hlive.nonce !== "") {
+            clone.nonce = hlive.nonce;
+        }
+
         clone.text = el.text;
 
         el.parentNode.replaceChild(clone, el);
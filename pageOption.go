@@ -1,6 +1,9 @@
 package hlive
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 type PageOption func(*Page)
 
@@ -10,6 +13,14 @@ func PageOptionCache(cache Cache) func(*Page) {
 	}
 }
 
+// PageOptionCacheTTL is how long server side renders are kept in a cache that implements CacheAdvanced. A browser
+// that connects after this has its Page rendered again.
+func PageOptionCacheTTL(ttl time.Duration) func(*Page) {
+	return func(p *Page) {
+		p.cacheTTL = ttl
+	}
+}
+
 func PageOptionEventBindingCache(m *sync.Map) func(*Page) {
 	return func(page *Page) {
 		page.eventBindings = m
@@ -3,6 +3,8 @@ package hlive
 import (
 	"context"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"log/slog"
@@ -16,6 +18,10 @@ func NewPageServer(pf func() *Page) *PageServer {
 }
 
 func NewPageServerWithSessionStore(pf func() *Page, sess *PageSessionStore) *PageServer {
+	return newPageServer(func(*http.Request) *Page { return pf() }, sess)
+}
+
+func newPageServer(pf func(r *http.Request) *Page, sess *PageSessionStore) *PageServer {
 	return &PageServer{
 		pageFunc: pf,
 		Sessions: sess,
@@ -26,8 +32,30 @@ func NewPageServerWithSessionStore(pf func() *Page, sess *PageSessionStore) *Pag
 type PageServer struct {
 	Sessions *PageSessionStore
 	Upgrader websocket.Upgrader
+	// Navigate returns the Page for a live navigation request, see Nav.
+	// Return nil, or leave unset, to have the browser do a normal page load.
+	Navigate func(r *http.Request) *Page
+	// AllowedOrigins for WebSocket connections, e.g. "https://example.com". Use "*" to allow all.
+	// When empty the Upgrader's origin check is used.
+	AllowedOrigins []string
+	// CSRFKey is the secret used to sign a token that's added to each server side render. The WebSocket connection
+	// must send a token created for the same browser.
+	CSRFKey []byte
+	// CSRFMaxAge is how long a CSRF token can be used for, CSRFMaxAgeDefault when zero
+	CSRFMaxAge time.Duration
+	// Identity returns the app's identity for the request, e.g. a user ID. The CSRF token is bound to it, so it
+	// can't be used by a different identity. When unset, or empty, the token is bound to a cookie if CSRFKey is set.
+	// Sessions are also grouped by it, see PageSessionStore.SessionsFor and Broadcast.
+	Identity func(r *http.Request) string
+	// Limits for each session, see Limits
+	Limits Limits
+	// CSP enables a script nonce for each server side render. It returns the Content-Security-Policy header value
+	// for the nonce, see CSPHeader. An empty value won't set the header.
+	CSP func(nonce string) string
+	// Assets to serve, see PageOptionAssets
+	Assets *Assets
 
-	pageFunc func() *Page
+	pageFunc func(r *http.Request) *Page
 	logger   *slog.Logger
 }
 
@@ -35,8 +63,57 @@ func (s *PageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// WebSocket?
 	sessID := r.URL.Query().Get("hlive")
 
+	if s.Assets != nil && strings.HasPrefix(r.URL.Path, AssetsPath) {
+		s.Assets.ServeHTTP(w, r)
+
+		return
+	}
+
 	if sessID == "" {
-		s.pageFunc().ServeHTTP(w, r)
+		page := s.pageFunc(r)
+		if page == nil {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		if len(s.CSRFKey) != 0 {
+			token, err := s.csrfToken(s.binding(w, r, true))
+			if err != nil {
+				s.logger.Error("csrf token", "error", err)
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			page.DOM().HTML().Add(Attrs{AttrCSRF: token})
+			page.cacheConnectOnly = true
+		}
+
+		if s.CSP != nil {
+			nonce, err := newScriptNonce()
+			if err != nil {
+				s.logger.Error("script nonce", "error", err)
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+
+			page.SetScriptNonce(nonce)
+
+			if csp := s.CSP(nonce); csp != "" {
+				w.Header().Set("Content-Security-Policy", csp)
+			}
+		}
+
+		page.ServeHTTP(w, r)
+
+		return
+	}
+
+	if err := s.checkUpgrade(r); err != nil {
+		s.logger.Warn("ws connect: rejected", "error", err, "origin", r.Header.Get("Origin"))
+		w.WriteHeader(http.StatusForbidden)
 
 		return
 	}
@@ -44,14 +121,42 @@ func (s *PageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var sess *PageSession
 	// New
 	if sessID == "1" {
+		page := s.pageFunc(r)
+		if page == nil {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		// Scripts sent in diffs need the nonce the browser has
+		if s.CSP != nil {
+			page.SetScriptNonce(r.URL.Query().Get("hnonce"))
+		}
+
+		// Render the token the browser has, so we match the server side render
+		if len(s.CSRFKey) != 0 {
+			page.DOM().HTML().Add(Attrs{AttrCSRF: r.URL.Query().Get("hcsrf")})
+		}
+
 		sess = s.Sessions.New()
 		sess.muSess.Lock()
-		sess.page = s.pageFunc()
+		sess.page = page
+		sess.limits = s.Limits
+		sess.limiter = newTokenBucket(s.Limits.EventsPerSecond, s.Limits.EventsBurst)
 		sess.connectedAt = time.Now()
 		sess.lastActive = sess.connectedAt
-		sess.ctxInitial, sess.ctxInitialCancel = context.WithCancel(r.Context())
+		ctx := context.WithValue(r.Context(), CtxSession, sess)
+		ctx = context.WithValue(ctx, CtxRequest, r)
+		ctx = context.WithValue(ctx, ctxNavigate, navigator(func(ctx context.Context, target string) bool {
+			return s.navigate(ctx, sess, target)
+		}))
+		sess.ctxInitial, sess.ctxInitialCancel = context.WithCancel(ctx)
 		sess.ctxPage, sess.ctxPageCancel = context.WithCancel(sess.ctxInitial)
 		sess.muSess.Unlock()
+
+		if s.Identity != nil {
+			s.Sessions.setIdentity(sess, s.Identity(r))
+		}
 	} else { // Reconnect
 		// TODO: need to rethink reconnect and double check my assumptions
 		//sess = s.Sessions.Get(sessID)
@@ -88,31 +193,47 @@ func (s *PageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	hhash := r.URL.Query().Get("hhash")
 
-	s.logger = sess.GetPage().logger
-	s.logger.Debug("ws start", "sessionID", sessID, "hash", hhash)
+	logger := sess.GetPage().logger
+	logger.Debug("ws start", "sessionID", sessID, "hash", hhash)
 
 	if sess.GetPage().cache != nil && hhash != "" && sessID == "1" {
 		val, hit := sess.GetPage().cache.Get(hhash)
 
 		b, ok := val.([]byte)
 		if hit && ok {
-			s.logger.Debug("cache get", "hit", hit, "hhash", hhash, "size", len(b)/1024)
+			logger.Debug("cache get", "hit", hit, "hhash", hhash, "size", len(b)/1024)
 			newTree := G()
 			if err := msgpack.Unmarshal(b, newTree); err != nil {
-				s.logger.Error("ServeHTTP: msgpack.Unmarshal", "error", err)
+				logger.Error("ServeHTTP: msgpack.Unmarshal", "error", err)
+				cacheDelete(sess.GetPage().cache, hhash)
 			} else {
 				sess.GetPage().domBrowser = newTree
 			}
+
+			// The token makes each render unique, so it won't be used again
+			if len(s.CSRFKey) != 0 {
+				cacheDelete(sess.GetPage().cache, hhash)
+			}
+		}
+
+		// We'll need a new render, check it against what the browser has
+		if sess.GetPage().domBrowser == nil {
+			sess.GetPage().hydrationHash = hhash
 		}
 	}
 
 	sess.muSess.Lock()
 
 	var err error
-	sess.wsConn, err = s.Upgrader.Upgrade(w, r, nil)
+	upgrader := s.Upgrader
+	if len(s.AllowedOrigins) != 0 {
+		upgrader.CheckOrigin = s.originAllowed
+	}
+
+	sess.wsConn, err = upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		sess.muSess.Unlock()
-		s.logger.Error("ws upgrade", "error", err)
+		logger.Error("ws upgrade", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 
 		return
@@ -126,11 +247,92 @@ func (s *PageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	go sess.writePump()
 	go sess.readPump()
 
-	if err := sess.GetPage().ServeWS(sess.GetContextPage(), sess.GetID(), sess.Send, sess.Receive); err != nil {
-		sess.GetPage().logger.Error("ws serve", "error", err)
-		w.WriteHeader(http.StatusInternalServerError)
+	// Serve the Page, then each Page we navigate to
+	for page := sess.GetPage(); page != nil; page = s.handoff(sess, page) {
+		if err := page.ServeWS(sess.GetContextPage(), sess.GetID(), sess.Send, sess.Receive); err != nil {
+			page.logger.Error("ws serve", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
 	}
 
 	// This needs to say open to keep the context active
 	<-sess.done
 }
+
+// navigate creates the Page for the target URL and stops the current Page so the connection can be handed over
+func (s *PageServer) navigate(ctx context.Context, sess *PageSession, target string) bool {
+	if s.Navigate == nil {
+		return false
+	}
+
+	r, ok := RequestFromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	u, err := r.URL.Parse(target)
+	if err != nil {
+		s.logger.Error("navigate: parse url", "error", err, "url", target)
+
+		return false
+	}
+
+	// Only same origin
+	if u.Scheme != "" || u.Host != "" {
+		return false
+	}
+
+	nr := r.Clone(sess.GetContextInitial())
+	nr.URL = &url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery}
+	nr.RequestURI = nr.URL.RequestURI()
+
+	next := s.Navigate(nr)
+	if next == nil {
+		return false
+	}
+
+	sess.muSess.Lock()
+	sess.pageNext = next
+	sess.pageNextRequest = nr
+	cancel := sess.ctxPageCancel
+	sess.muSess.Unlock()
+
+	cancel()
+
+	return true
+}
+
+// handoff closes the previous Page and makes the next Page the session's Page.
+// Returns nil when there isn't a next Page.
+func (s *PageServer) handoff(sess *PageSession, prev *Page) *Page {
+	sess.muSess.Lock()
+	next, r := sess.pageNext, sess.pageNextRequest
+	sess.pageNext, sess.pageNextRequest = nil, nil
+	ctxInitial := sess.ctxInitial
+	sess.muSess.Unlock()
+
+	if next == nil || ctxInitial.Err() != nil {
+		return nil
+	}
+
+	prev.Close(sess.GetContextPage())
+
+	// The browser still has the previous Page's DOM, so we only need to send the differences.
+	// The previous Page's handlers can still be running, they can't send or change the DOM after this.
+	prev.detach(next)
+
+	if next.GetScriptNonce() == "" {
+		next.SetScriptNonce(prev.GetScriptNonce())
+	}
+
+	ctx := context.WithValue(ctxInitial, CtxRequest, r)
+
+	sess.muSess.Lock()
+	sess.page = next
+	sess.ctxPage, sess.ctxPageCancel = context.WithCancel(ctx)
+	sess.muSess.Unlock()
+
+	s.logger.Debug("navigate", "sessionID", sess.GetID(), "url", r.URL.String())
+
+	return next
+}
@@ -0,0 +1,138 @@
+package hlive
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Security errors
+var (
+	ErrOriginNotAllowed = errors.New("origin not allowed")
+	ErrCSRFInvalid      = errors.New("invalid csrf token")
+)
+
+// Session binding cookie, used when there is no Identity
+const BindingCookieName = "hlive_bind"
+
+const bindingSize = 32
+
+// CSRFMaxAgeDefault is how long a CSRF token can be used for when PageServer.CSRFMaxAge isn't set
+const CSRFMaxAgeDefault = 12 * time.Hour
+
+// originAllowed checks the Origin header against PageServer.AllowedOrigins.
+// A request without an Origin header didn't come from a browser, so it's allowed.
+func (s *PageServer) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for i := 0; i < len(s.AllowedOrigins); i++ {
+		if s.AllowedOrigins[i] == "*" || strings.EqualFold(s.AllowedOrigins[i], origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// binding returns what this browser's sessions are bound to. The app's Identity if it has one, else the binding
+// cookie. When create is true and there isn't a binding cookie, one is set.
+func (s *PageServer) binding(w http.ResponseWriter, r *http.Request, create bool) string {
+	if s.Identity != nil {
+		if id := s.Identity(r); id != "" {
+			return "id:" + id
+		}
+	}
+
+	if c, err := r.Cookie(BindingCookieName); err == nil && c.Value != "" {
+		return "ck:" + c.Value
+	}
+
+	if !create || len(s.CSRFKey) == 0 {
+		return ""
+	}
+
+	b := make([]byte, bindingSize)
+	if _, err := rand.Read(b); err != nil {
+		s.logger.Error("binding: random", "error", err)
+
+		return ""
+	}
+
+	value := base64.RawURLEncoding.EncodeToString(b)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     BindingCookieName,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return "ck:" + value
+}
+
+// csrfToken creates a new token for the binding: nonce.issued.mac, issued is in Unix milliseconds
+func (s *PageServer) csrfToken(binding string) (string, error) {
+	nonce := make([]byte, bindingSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	n := base64.RawURLEncoding.EncodeToString(nonce)
+	issued := strconv.FormatInt(time.Now().UnixMilli(), base10)
+
+	return n + "." + issued + "." + s.csrfMAC(n, issued, binding), nil
+}
+
+func (s *PageServer) csrfMAC(nonce, issued, binding string) string {
+	mac := hmac.New(sha256.New, s.CSRFKey)
+	mac.Write([]byte(nonce + "|" + issued + "|" + binding))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s *PageServer) csrfValid(token, binding string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] == "" || binding == "" {
+		return false
+	}
+
+	if !hmac.Equal([]byte(parts[2]), []byte(s.csrfMAC(parts[0], parts[1], binding))) {
+		return false
+	}
+
+	issued, err := strconv.ParseInt(parts[1], base10, bit64)
+	if err != nil {
+		return false
+	}
+
+	maxAge := s.CSRFMaxAge
+	if maxAge == 0 {
+		maxAge = CSRFMaxAgeDefault
+	}
+
+	return time.Since(time.UnixMilli(issued)) <= maxAge
+}
+
+// checkUpgrade makes sure a new WebSocket connection is allowed
+func (s *PageServer) checkUpgrade(r *http.Request) error {
+	if len(s.AllowedOrigins) != 0 && !s.originAllowed(r) {
+		return ErrOriginNotAllowed
+	}
+
+	if len(s.CSRFKey) != 0 && !s.csrfValid(r.URL.Query().Get("hcsrf"), s.binding(nil, r, false)) {
+		return ErrCSRFInvalid
+	}
+
+	return nil
+}
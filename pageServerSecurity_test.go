@@ -0,0 +1,138 @@
+package hlive_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/gorilla/websocket"
+)
+
+var csrfRegexp = regexp.MustCompile(l.AttrCSRF + `="([^"]+)"`)
+
+func newSecurePageServer(t *testing.T, maxAge time.Duration) string {
+	t.Helper()
+
+	s := l.NewPageServer(func() *l.Page {
+		return l.NewPage()
+	})
+	s.CSRFKey = []byte("test key")
+	s.CSRFMaxAge = maxAge
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	return ts.URL
+}
+
+func dialStatus(t *testing.T, u string, header http.Header) int {
+	t.Helper()
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(u, "http"), header)
+	if err == nil {
+		_ = conn.Close()
+	}
+
+	if resp == nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode
+}
+
+func getCSRFToken(t *testing.T, serverURL string) ([]string, []*http.Cookie) {
+	t.Helper()
+
+	resp, err := http.Get(serverURL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = resp.Body.Close()
+
+	match := csrfRegexp.FindStringSubmatch(string(body))
+	if match == nil || len(resp.Cookies()) == 0 {
+		t.Fatal("csrf token not rendered")
+	}
+
+	return match, resp.Cookies()
+}
+
+func TestPageServer_CSRF(t *testing.T) {
+	t.Parallel()
+
+	serverURL := newSecurePageServer(t, 0)
+	match, cookies := getCSRFToken(t, serverURL)
+
+	if cookies[0].Name != l.BindingCookieName || !cookies[0].HttpOnly {
+		t.Fatal("binding cookie not set")
+	}
+
+	header := http.Header{"Cookie": []string{cookies[0].Name + "=" + cookies[0].Value}}
+	withToken := serverURL + "/?hlive=1&hcsrf=" + url.QueryEscape(match[1])
+
+	if code := dialStatus(t, withToken, header); code != http.StatusSwitchingProtocols {
+		t.Error("expected upgrade, got:", code)
+	}
+
+	if code := dialStatus(t, serverURL+"/?hlive=1", header); code != http.StatusForbidden {
+		t.Error("expected forbidden without token, got:", code)
+	}
+
+	// A token from another browser
+	other := http.Header{"Cookie": []string{l.BindingCookieName + "=other"}}
+	if code := dialStatus(t, withToken, other); code != http.StatusForbidden {
+		t.Error("expected forbidden for other browser, got:", code)
+	}
+}
+
+func TestPageServer_CSRFMaxAge(t *testing.T) {
+	t.Parallel()
+
+	serverURL := newSecurePageServer(t, 200*time.Millisecond)
+	match, cookies := getCSRFToken(t, serverURL)
+
+	header := http.Header{"Cookie": []string{cookies[0].Name + "=" + cookies[0].Value}}
+	withToken := serverURL + "/?hlive=1&hcsrf=" + url.QueryEscape(match[1])
+
+	if code := dialStatus(t, withToken, header); code != http.StatusSwitchingProtocols {
+		t.Error("expected upgrade, got:", code)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	if code := dialStatus(t, withToken, header); code != http.StatusForbidden {
+		t.Error("expected forbidden for an old token, got:", code)
+	}
+}
+
+func TestPageServer_AllowedOrigins(t *testing.T) {
+	t.Parallel()
+
+	s := l.NewPageServer(func() *l.Page {
+		return l.NewPage()
+	})
+	s.AllowedOrigins = []string{"https://good.example"}
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	if code := dialStatus(t, ts.URL+"/?hlive=1", http.Header{"Origin": []string{"https://bad.example"}}); code != http.StatusForbidden {
+		t.Error("expected forbidden, got:", code)
+	}
+
+	if code := dialStatus(t, ts.URL+"/?hlive=1", http.Header{"Origin": []string{"https://good.example"}}); code != http.StatusSwitchingProtocols {
+		t.Error("expected upgrade, got:", code)
+	}
+}
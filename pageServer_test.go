@@ -0,0 +1,142 @@
+package hlive_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/gorilla/websocket"
+)
+
+func dialPageServer(t *testing.T, s *l.PageServer) *websocket.Conn {
+	t.Helper()
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/?hlive=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func waitForMessage(t *testing.T, conn *websocket.Conn, contains string) {
+	t.Helper()
+
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("waiting for %q: %v", contains, err)
+		}
+
+		if strings.Contains(string(msg), contains) {
+			return
+		}
+	}
+}
+
+func TestPageServer_Navigate(t *testing.T) {
+	t.Parallel()
+
+	newPage := func(text string) *l.Page {
+		page := l.NewPage()
+		page.DOM().Body().Add(l.T("a", l.Nav(), l.Attrs{"href": "/b"}), l.T("p", text))
+
+		return page
+	}
+
+	s := l.NewPageServer(func() *l.Page { return newPage("Page A") })
+	s.Navigate = func(r *http.Request) *l.Page {
+		if r.URL.Path != "/b" {
+			return nil
+		}
+
+		return newPage("Page B " + r.URL.Query().Get("q"))
+	}
+
+	conn := dialPageServer(t, s)
+
+	waitForMessage(t, conn, "s|id|")
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"t":"n","d":{"u":"/b?q=1"}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the changed text is sent
+	waitForMessage(t, conn, "|t|"+base64.StdEncoding.EncodeToString([]byte("Page B 1")))
+
+	// No Page for this URL
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"t":"n","d":{"u":"/c"}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForMessage(t, conn, "n|l|"+base64.StdEncoding.EncodeToString([]byte("/c")))
+}
+
+func TestPageServer_NavigateOldPageSend(t *testing.T) {
+	t.Parallel()
+
+	text := l.Box("Page A")
+	comp := l.C("p", text)
+	mounted := make(chan context.Context, 1)
+
+	s := l.NewPageServer(func() *l.Page {
+		page := l.NewPage()
+		page.DOM().Body().Add(comp)
+		page.HookMountAdd(func(ctx context.Context, _ *l.Page) {
+			mounted <- ctx
+		})
+
+		return page
+	})
+	s.Navigate = func(_ *http.Request) *l.Page {
+		page := l.NewPage()
+		page.DOM().Body().Add(l.T("p", "Page B"))
+
+		return page
+	}
+
+	conn := dialPageServer(t, s)
+
+	ctxA := <-mounted
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"t":"n","d":{"u":"/b"}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForMessage(t, conn, "|t|"+base64.StdEncoding.EncodeToString([]byte("Page B")))
+
+	// Page A's handlers can still be running
+	text.Set("Old Page")
+	l.Render(ctxA)
+	l.RenderComponent(context.WithValue(context.Background(), l.CtxRenderComponent,
+		ctxA.Value(l.CtxRenderComponent)), comp)
+
+	if err := conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		if strings.HasPrefix(string(msg), "d|") {
+			t.Fatalf("old Page sent a diff: %s", msg)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package hlive
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"sync"
 	"time"
 
@@ -21,6 +23,12 @@ type PageSession struct {
 	connectedAt      time.Time
 	lastActive       time.Time
 	page             *Page
+	pageNext         *Page
+	pageNextRequest  *http.Request
+	identity         string
+	limits           Limits
+	limiter          *tokenBucket
+	inFlight         int32
 	ctxInitial       context.Context //nolint:containedctx // we are a router and create new contexts from this one
 	ctxPage          context.Context //nolint:containedctx // we are a router and create new contexts from this one
 	ctxPageCancel    context.CancelFunc
@@ -60,7 +68,10 @@ func (sess *PageSession) readPump() {
 
 	sess.muWrite.Lock()
 
-	// c.conn.SetReadLimit(maxMessageSize)
+	if sess.limits.MaxMessageSize > 0 {
+		sess.wsConn.SetReadLimit(sess.limits.MaxMessageSize)
+	}
+
 	if err := sess.wsConn.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
 		sess.logger.Error("read pump set read deadline", "error", err)
 	}
@@ -90,6 +101,10 @@ func (sess *PageSession) readPump() {
 
 			mt, message, err := sess.wsConn.ReadMessage()
 			if err != nil {
+				if errors.Is(err, websocket.ErrReadLimit) {
+					sess.violation(sess.GetContextInitial(), LimitMessageSize)
+				}
+
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 					sess.logger.Debug("unexpected close error", "error", err)
 				}
@@ -103,6 +118,12 @@ func (sess *PageSession) readPump() {
 			sess.lastActive = time.Now()
 			sess.muSess.Unlock()
 
+			if !sess.limiter.allow(time.Now()) {
+				sess.violation(sess.GetContextInitial(), LimitEventRate)
+
+				continue
+			}
+
 			sess.Receive <- MessageWS{
 				Message:  message,
 				IsBinary: mt == websocket.BinaryMessage,
@@ -204,6 +225,14 @@ func (sess *PageSession) SetPage(page *Page) {
 	sess.muSess.Unlock()
 }
 
+// GetIdentity is the PageServer.Identity of the request that created the session, empty if there isn't one
+func (sess *PageSession) GetIdentity() string {
+	sess.muSess.RLock()
+	defer sess.muSess.RUnlock()
+
+	return sess.identity
+}
+
 func (sess *PageSession) GetID() string {
 	sess.muSess.RLock()
 	defer sess.muSess.RUnlock()
@@ -1,6 +1,7 @@
 package hlive
 
 import (
+	"context"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -32,6 +33,9 @@ type PageSessionStore struct {
 	sessionCount          uint32
 	GarbageCollectionTick time.Duration
 	Done                  chan bool
+	// Sessions by identity and id
+	identities   map[string]map[string]*PageSession
+	muIdentities sync.RWMutex
 }
 
 // New PageSession.
@@ -76,9 +80,93 @@ func (pss *PageSessionStore) mapGet(id string) *PageSession {
 }
 
 func (pss *PageSessionStore) mapDelete(id string) {
-	if _, loaded := pss.sessions.LoadAndDelete(id); loaded {
+	if value, loaded := pss.sessions.LoadAndDelete(id); loaded {
 		atomic.AddUint32(&pss.sessionCount, ^uint32(0))
+
+		if sess, ok := value.(*PageSession); ok {
+			pss.identityDelete(sess)
+		}
+	}
+}
+
+// Group the session with the others for the identity
+func (pss *PageSessionStore) setIdentity(sess *PageSession, identity string) {
+	if identity == "" {
+		return
+	}
+
+	sess.muSess.Lock()
+	sess.identity = identity
+	sess.muSess.Unlock()
+
+	pss.muIdentities.Lock()
+	defer pss.muIdentities.Unlock()
+
+	if pss.identities == nil {
+		pss.identities = map[string]map[string]*PageSession{}
 	}
+
+	if pss.identities[identity] == nil {
+		pss.identities[identity] = map[string]*PageSession{}
+	}
+
+	pss.identities[identity][sess.GetID()] = sess
+}
+
+func (pss *PageSessionStore) identityDelete(sess *PageSession) {
+	identity := sess.GetIdentity()
+	if identity == "" {
+		return
+	}
+
+	pss.muIdentities.Lock()
+	defer pss.muIdentities.Unlock()
+
+	delete(pss.identities[identity], sess.GetID())
+
+	if len(pss.identities[identity]) == 0 {
+		delete(pss.identities, identity)
+	}
+}
+
+// SessionsFor returns the sessions for an identity, for example all the open tabs of a user
+func (pss *PageSessionStore) SessionsFor(identity string) []*PageSession {
+	pss.muIdentities.RLock()
+	defer pss.muIdentities.RUnlock()
+
+	sessions := make([]*PageSession, 0, len(pss.identities[identity]))
+	for _, sess := range pss.identities[identity] {
+		sessions = append(sessions, sess)
+	}
+
+	return sessions
+}
+
+// Broadcast calls f for the Page of each connected session for identity, at the same time, and waits for them.
+// Render works with ctx, for example to show a notification.
+func (pss *PageSessionStore) Broadcast(identity string, f func(ctx context.Context, page *Page)) {
+	sessions := pss.SessionsFor(identity)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < len(sessions); i++ {
+		page := sessions[i].GetPage()
+		ctx := sessions[i].GetContextPage()
+
+		if page == nil || ctx == nil || ctx.Err() != nil || !sessions[i].IsConnected() {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			f(page.contextWS(ctx), page)
+		}()
+	}
+
+	wg.Wait()
 }
 
 func (pss *PageSessionStore) GarbageCollection() {
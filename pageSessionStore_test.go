@@ -0,0 +1,85 @@
+package hlive_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/gorilla/websocket"
+)
+
+func TestPageSessionStore_Broadcast(t *testing.T) {
+	t.Parallel()
+
+	s := l.NewPageServer(func() *l.Page {
+		page := l.NewPage()
+		page.DOM().Body().Add(l.T("p", "Hello"))
+
+		return page
+	})
+	s.Identity = func(r *http.Request) string {
+		return r.Header.Get("X-User")
+	}
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	dial := func(user string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/?hlive=1",
+			http.Header{"X-User": []string{user}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { _ = conn.Close() })
+
+		waitForMessage(t, conn, "d|c|doc")
+
+		return conn
+	}
+
+	// Two tabs for user 42
+	tabA := dial("42")
+	tabB := dial("42")
+	other := dial("7")
+
+	if got := len(s.Sessions.SessionsFor("42")); got != 2 {
+		t.Fatal("expected 2 sessions, got:", got)
+	}
+
+	s.Sessions.Broadcast("42", func(ctx context.Context, page *l.Page) {
+		page.DOM().Body().Add(l.T("p", "Notification"))
+		l.Render(ctx)
+	})
+
+	text := base64.StdEncoding.EncodeToString([]byte("Notification"))
+
+	waitForMessage(t, tabA, text)
+	waitForMessage(t, tabB, text)
+
+	if err := other.SetReadDeadline(time.Now().Add(100 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		_, msg, err := other.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		if strings.Contains(string(msg), text) {
+			t.Fatal("unexpected broadcast to another identity")
+		}
+	}
+
+	s.Sessions.Delete(s.Sessions.SessionsFor("42")[0].GetID())
+
+	if got := len(s.Sessions.SessionsFor("42")); got != 1 {
+		t.Fatal("expected 1 session after delete, got:", got)
+	}
+}
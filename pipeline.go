@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
 )
 
 var ErrDOMInvalidated = errors.New("dom invalidated")
@@ -29,10 +30,19 @@ type Pipeline struct {
 	beforeAttrCache   []*PipelineProcessor
 	afterAttrCache    []*PipelineProcessor
 	// Add new caches to RemoveAll
+
+	// Walked memoised subtrees, see Memo
+	memo     map[Tagger]pipelineMemo
+	memoSeen map[Tagger]struct{}
+	memoMu   sync.Mutex
 }
 
 func NewPipeline(pps ...*PipelineProcessor) *Pipeline {
-	p := &Pipeline{processorMap: map[string]*PipelineProcessor{}}
+	p := &Pipeline{
+		processorMap: map[string]*PipelineProcessor{},
+		memo:         map[Tagger]pipelineMemo{},
+		memoSeen:     map[Tagger]struct{}{},
+	}
 	p.Add(pps...)
 
 	return p
@@ -254,6 +264,10 @@ func (p *Pipeline) afterAttr(ctx context.Context, w io.Writer, attr Attributer)
 
 // Run all the steps
 func (p *Pipeline) run(ctx context.Context, w io.Writer, nodeGroup *NodeGroup) (*NodeGroup, error) {
+	p.memoMu.Lock()
+	p.memoSeen = map[Tagger]struct{}{}
+	p.memoMu.Unlock()
+
 	nodeGroup, err := p.beforeWalk(ctx, w, nodeGroup)
 	if err != nil {
 		return nil, fmt.Errorf("run: beforeWalk: %w", err)
@@ -275,9 +289,43 @@ func (p *Pipeline) run(ctx context.Context, w io.Writer, nodeGroup *NodeGroup) (
 		return nil, fmt.Errorf("run full tree: %w", err)
 	}
 
+	p.memoPrune()
+
 	return newGroup, nil
 }
 
+func (p *Pipeline) memoGet(tagger Tagger, key string) (*Tag, bool) {
+	p.memoMu.Lock()
+	defer p.memoMu.Unlock()
+
+	p.memoSeen[tagger] = struct{}{}
+
+	m, exists := p.memo[tagger]
+	if !exists || m.key != key {
+		return nil, false
+	}
+
+	return m.tag, true
+}
+
+func (p *Pipeline) memoSet(tagger Tagger, key string, tag *Tag) {
+	p.memoMu.Lock()
+	p.memo[tagger] = pipelineMemo{key: key, tag: tag}
+	p.memoMu.Unlock()
+}
+
+// Remove memoised subtrees that are no longer in the tree
+func (p *Pipeline) memoPrune() {
+	p.memoMu.Lock()
+	defer p.memoMu.Unlock()
+
+	for tagger := range p.memo {
+		if _, seen := p.memoSeen[tagger]; !seen {
+			delete(p.memo, tagger)
+		}
+	}
+}
+
 // Skips some steps
 func (p *Pipeline) runNode(ctx context.Context, w io.Writer, node any) (any, error) {
 	return p.walk(ctx, w, node)
@@ -299,6 +347,27 @@ func (p *Pipeline) walk(ctx context.Context, w io.Writer, node any) (any, error)
 			return nil, nil
 		}
 
+		// Memoised subtrees are only walked when their key changes
+		key := memoKey(v)
+		if key != "" {
+			if tag, hit := p.memoGet(v, key); hit {
+				return tag, nil
+			}
+		}
+
+		memoTagger := v
+
+		// Let descendants know their closest Component
+		if comp, ok := v.(Componenter); ok {
+			ctx = context.WithValue(ctx, CtxComponent, comp)
+		}
+
+		// Make provided values available to descendants
+		if provider, ok := v.(Provider); ok {
+			ctx = context.WithValue(ctx, ctxKeyProvidersParent, providerScopeFromContext(ctx))
+			ctx = provider.ProvideContext(ctx)
+		}
+
 		v, err := p.beforeTagger(ctx, w, v)
 		if err != nil {
 			return nil, err
@@ -338,7 +407,13 @@ func (p *Pipeline) walk(ctx context.Context, w io.Writer, node any) (any, error)
 			return tag, err
 		}
 
+		if key != "" {
+			p.memoSet(memoTagger, key, tag)
+		}
+
 		return tag, nil
+	case ContextNodeBoxer:
+		return p.walk(ctx, w, v.GetNodeContext(ctx))
 	//
 	// Lists, the following will all eventually be sent to the above simple node or Tagger cases
 	//
@@ -371,20 +446,28 @@ func (p *Pipeline) walk(ctx context.Context, w io.Writer, node any) (any, error)
 				continue
 			}
 
-			// Combine consecutive strings
-			thisNodeStr, thisNodeIsStr = node.(string)
-
-			// Combine strings like a browser would
-			if lastNodeIsStr && thisNodeIsStr && len(newGroup) > 0 {
-				// update this in case we have another string
-				thisNodeStr = lastNodeStr + thisNodeStr
-				// replace last node
-				newGroup[len(newGroup)-1] = thisNodeStr
-			} else {
-				newGroup = append(newGroup, node)
+			// Flatten nested groups so their strings can be combined with the ones around them
+			nodes, isGroup := node.([]any)
+			if !isGroup {
+				nodes = []any{node}
+			}
+
+			for j := 0; j < len(nodes); j++ {
+				// Combine consecutive strings
+				thisNodeStr, thisNodeIsStr = nodes[j].(string)
+
+				// Combine strings like a browser would
+				if lastNodeIsStr && thisNodeIsStr && len(newGroup) > 0 {
+					// update this in case we have another string
+					thisNodeStr = lastNodeStr + thisNodeStr
+					// replace last node
+					newGroup[len(newGroup)-1] = thisNodeStr
+				} else {
+					newGroup = append(newGroup, nodes[j])
+				}
+				// Update state for the next loop
+				lastNodeStr, lastNodeIsStr = thisNodeStr, thisNodeIsStr
 			}
-			// Update state for the next loop
-			lastNodeStr, lastNodeIsStr = thisNodeStr, thisNodeIsStr
 		}
 
 		return newGroup, nil
@@ -17,6 +17,7 @@ const (
 	PipelineProcessorKeyMount                = "hlive_mount"
 	PipelineProcessorKeyUnmount              = "hlive_unmount"
 	PipelineProcessorKeyConvertToString      = "hlive_conv_str"
+	PipelineProcessorKeyProviders            = "hlive_providers"
 )
 
 type PipelineProcessor struct {
@@ -54,9 +55,48 @@ func PipelineProcessorEventBindingCache(cache *sync.Map) *PipelineProcessor {
 	return pp
 }
 
-func PipelineProcessorMount() *PipelineProcessor {
-	var compID uint64
+// PipelineProcessorProviders remembers the provided values for each event binding and Component, so they can be used
+// in event handlers and when a Component is rendered on its own
+func PipelineProcessorProviders(page *Page) *PipelineProcessor {
+	pp := NewPipelineProcessor(PipelineProcessorKeyProviders)
+
+	pp.BeforeTagger = func(ctx context.Context, w io.Writer, tag Tagger) (Tagger, error) {
+		if comp, ok := tag.(Componenter); ok {
+			scope := providerScopeFromContext(ctx)
+
+			// A Provider's own values are added again when it's rendered on its own
+			above := scope
+			if _, ok := tag.(Provider); ok {
+				above, _ = ctx.Value(ctxKeyProvidersParent).(*providerScope)
+			}
 
+			if above == nil {
+				page.componentProviders.Delete(comp.GetID())
+			} else {
+				page.componentProviders.Store(comp.GetID(), above)
+			}
+
+			bindings := comp.GetEventBindings()
+
+			for i := 0; i < len(bindings); i++ {
+				if scope == nil {
+					page.eventProviders.Delete(bindings[i].ID)
+				} else {
+					page.eventProviders.Store(bindings[i].ID, scope)
+				}
+			}
+		}
+
+		return tag, nil
+	}
+
+	return pp
+}
+
+// Component IDs are unique across all Pages so a connection can be handed from Page to Page
+var compID uint64
+
+func PipelineProcessorMount() *PipelineProcessor {
 	pp := NewPipelineProcessor(PipelineProcessorKeyMount)
 
 	pp.BeforeTagger = func(ctx context.Context, w io.Writer, tag Tagger) (Tagger, error) {
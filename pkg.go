@@ -15,6 +15,9 @@ const (
 	AttrID     = "hid"
 	AttrOn     = "hon"
 	AttrUpload = "data-hlive-upload"
+	AttrMemo   = "data-hlive-memo"
+	AttrNav    = "data-hlive-nav"
+	AttrCSRF   = "data-hlive-csrf"
 	base10     = 10
 	bit32      = 32
 	bit64      = 64
@@ -34,6 +37,14 @@ type CtxKey string
 const (
 	CtxRender          CtxKey = "render"
 	CtxRenderComponent CtxKey = "render_comp"
+	// CtxComponent is the closest Component to the Node being walked by a pipeline
+	CtxComponent CtxKey = "component"
+	// CtxPage is the Page being rendered or handling an event
+	CtxPage CtxKey = "page"
+	// CtxSession is the PageSession of a WebSocket connection
+	CtxSession CtxKey = "session"
+	// CtxRequest is the HTTP request that started the render or the WebSocket connection
+	CtxRequest CtxKey = "request"
 )
 
 type DiffType string
@@ -56,7 +67,4 @@ const (
 
 	// Send pings to peer with this period. Must be less than pongWait.
 	pingPeriod = (pongWait * 9) / 10
-
-	// Maximum message size allowed from peer.
-	// maxMessageSize = 512
 )
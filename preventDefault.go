@@ -26,9 +26,9 @@ type PreventDefaultAttribute struct {
 }
 
 func (a *PreventDefaultAttribute) Initialize(page *Page) {
-	page.DOM().Head().Add(T("script", HTML(PreventDefaultJavaScript)))
+	page.ScriptAdd(PreventDefaultJavaScript)
 }
 
 func (a *PreventDefaultAttribute) InitializeSSR(page *Page) {
-	page.DOM().Head().Add(T("script", HTML(PreventDefaultJavaScript)))
+	page.ScriptAdd(PreventDefaultJavaScript)
 }
@@ -0,0 +1,160 @@
+package hlive
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	ctxKeyProviders CtxKey = "providers"
+	// The scope above the closest Provider
+	ctxKeyProvidersParent CtxKey = "providers_parent"
+)
+
+// Provider is a Tagger that makes values available to its descendants.
+//
+// The values can be looked up during a pipeline walk, in Mount, and in event handlers, using a ProviderKey.
+type Provider interface {
+	Tagger
+	// ProvideContext returns a context with this Provider's values added
+	ProvideContext(ctx context.Context) context.Context
+}
+
+// ProviderKey is a typed key used to provide and look up a value.
+//
+// Create one with NewProviderKey and share it between the Provider and its descendants.
+type ProviderKey[T any] struct {
+	name string
+}
+
+// NewProviderKey creates a new ProviderKey. The name is only used for debugging.
+func NewProviderKey[T any](name string) *ProviderKey[T] {
+	return &ProviderKey[T]{name: name}
+}
+
+// GetName returns the name of this key
+func (k *ProviderKey[T]) GetName() string {
+	return k.name
+}
+
+// Provide creates a value for this key that can be added to a ComponentProvider.
+func (k *ProviderKey[T]) Provide(value T) ProvidedValue {
+	return ProvidedValue{key: k, value: value}
+}
+
+// Get returns the value provided by the closest Provider. If no Provider has a value for this key, false is returned.
+func (k *ProviderKey[T]) Get(ctx context.Context) (T, bool) {
+	var zero T
+
+	if ctx == nil {
+		return zero, false
+	}
+
+	scope, _ := ctx.Value(ctxKeyProviders).(*providerScope)
+	for ; scope != nil; scope = scope.parent {
+		for i := len(scope.values) - 1; i >= 0; i-- {
+			if scope.values[i].key != k {
+				continue
+			}
+
+			val, ok := scope.values[i].value.(T)
+
+			return val, ok
+		}
+	}
+
+	return zero, false
+}
+
+// ProvidedValue is a value for a ProviderKey, see ProviderKey.Provide.
+type ProvidedValue struct {
+	key   any
+	value any
+}
+
+// Each Provider adds a scope that links to the scope of the closest Provider above it
+type providerScope struct {
+	parent *providerScope
+	values []ProvidedValue
+}
+
+func providerScopeFromContext(ctx context.Context) *providerScope {
+	scope, _ := ctx.Value(ctxKeyProviders).(*providerScope)
+
+	return scope
+}
+
+// ComponentProvider is a Component that implements Provider.
+type ComponentProvider struct {
+	*Component
+
+	values []ProvidedValue
+	mu     sync.RWMutex
+}
+
+// CP is a shortcut for NewComponentProvider.
+func CP(name string, elements ...any) *ComponentProvider {
+	return NewComponentProvider(name, elements...)
+}
+
+// NewComponentProvider is a constructor for ComponentProvider.
+//
+// You can add ProvidedValue values along with any other elements.
+func NewComponentProvider(name string, elements ...any) *ComponentProvider {
+	c := &ComponentProvider{
+		Component: NewComponent(name),
+	}
+
+	c.Add(elements...)
+
+	return c
+}
+
+// Add an element to this ComponentProvider.
+//
+// Adding a ProvidedValue for a key that already has a value replaces it.
+func (c *ComponentProvider) Add(elements ...any) {
+	if c.IsNil() {
+		return
+	}
+
+	for i := 0; i < len(elements); i++ {
+		switch v := elements[i].(type) {
+		case ProvidedValue:
+			c.provide(v)
+		case []ProvidedValue:
+			for j := 0; j < len(v); j++ {
+				c.provide(v[j])
+			}
+		default:
+			c.Component.Add(v)
+		}
+	}
+}
+
+func (c *ComponentProvider) provide(value ProvidedValue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := 0; i < len(c.values); i++ {
+		if c.values[i].key == value.key {
+			c.values[i] = value
+
+			return
+		}
+	}
+
+	c.values = append(c.values, value)
+}
+
+// ProvideContext returns a context with this Provider's values added.
+func (c *ComponentProvider) ProvideContext(ctx context.Context) context.Context {
+	c.mu.RLock()
+	values := append([]ProvidedValue{}, c.values...)
+	c.mu.RUnlock()
+
+	return context.WithValue(ctx, ctxKeyProviders, &providerScope{
+		parent: providerScopeFromContext(ctx),
+		values: values,
+	})
+}
@@ -0,0 +1,194 @@
+package hlive_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/go-test/deep"
+)
+
+func TestProvider_MountGetsClosestValue(t *testing.T) {
+	t.Parallel()
+
+	theme := l.NewProviderKey[string]("theme")
+
+	var outer, inner string
+
+	outerComp := l.CM("div")
+	outerComp.SetMount(func(ctx context.Context) {
+		outer, _ = theme.Get(ctx)
+	})
+
+	innerComp := l.CM("div")
+	innerComp.SetMount(func(ctx context.Context) {
+		inner, _ = theme.Get(ctx)
+	})
+
+	page := l.NewPage()
+	page.DOM().Body().Add(
+		l.CP("div", theme.Provide("dark"),
+			outerComp,
+			l.CP("div", theme.Provide("light"), innerComp),
+		),
+	)
+
+	if _, err := page.RunDiffPipeline(context.Background(), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal("dark", outer); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal("light", inner); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestProvider_GetWithoutProvider(t *testing.T) {
+	t.Parallel()
+
+	key := l.NewProviderKey[int]("missing")
+
+	val, ok := key.Get(context.Background())
+	if ok {
+		t.Error("expected no value")
+	}
+
+	if diff := deep.Equal(0, val); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestProvider_ProvideReplacesValue(t *testing.T) {
+	t.Parallel()
+
+	key := l.NewProviderKey[int]("count")
+
+	provider := l.CP("div", key.Provide(1))
+	provider.Add(key.Provide(2))
+
+	val, _ := key.Get(provider.ProvideContext(context.Background()))
+	if diff := deep.Equal(2, val); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestProvider_EventHandlerGetsValue(t *testing.T) {
+	t.Parallel()
+
+	user := l.NewProviderKey[string]("user")
+	got := make(chan string, 1)
+
+	comp := l.C("button", l.On("click", func(ctx context.Context, _ l.Event) {
+		val, _ := user.Get(ctx)
+		got <- val
+	}))
+
+	page := l.NewPage()
+	page.DOM().Body().Add(l.CP("div", user.Provide("alice"), comp))
+
+	mounted := make(chan struct{})
+	page.HookMountAdd(func(_ context.Context, _ *l.Page) {
+		close(mounted)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	send := make(chan l.MessageWS, 10)
+	receive := make(chan l.MessageWS)
+
+	go func() {
+		_ = page.ServeWS(ctx, "sess", send, receive)
+	}()
+
+	select {
+	case <-mounted:
+	case <-time.After(time.Second):
+		t.Fatal("page not mounted")
+	}
+
+	id := comp.GetEventBindings()[0].ID
+
+	receive <- l.MessageWS{Message: []byte(`{"t":"e","i":"` + id + `"}`)}
+
+	select {
+	case val := <-got:
+		if diff := deep.Equal("alice", val); diff != nil {
+			t.Error(diff)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler not called")
+	}
+}
+
+func TestProvider_RenderComponentKeepsValues(t *testing.T) {
+	t.Parallel()
+
+	user := l.NewProviderKey[string]("user")
+	got := make(chan string, 2)
+
+	consumer := l.CM("div", l.On("click", func(ctx context.Context, _ l.Event) {
+		val, _ := user.Get(ctx)
+		got <- val
+	}))
+
+	var render any
+
+	consumer.SetMount(func(ctx context.Context) {
+		render = ctx.Value(l.CtxRenderComponent)
+	})
+
+	page := l.NewPage()
+	page.DOM().Body().Add(l.CP("div", user.Provide("alice"), l.T("div", consumer)))
+
+	mounted := make(chan struct{})
+	page.HookMountAdd(func(_ context.Context, _ *l.Page) {
+		close(mounted)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	send := make(chan l.MessageWS, 10)
+	receive := make(chan l.MessageWS)
+
+	go func() {
+		_ = page.ServeWS(ctx, "sess", send, receive)
+	}()
+
+	select {
+	case <-mounted:
+	case <-time.After(time.Second):
+		t.Fatal("page not mounted")
+	}
+
+	// Mounted by the render, it needs the values from above consumer
+	child := l.CM("span")
+	child.SetMount(func(ctx context.Context) {
+		val, _ := user.Get(ctx)
+		got <- val
+	})
+
+	consumer.Add(child)
+
+	// Not from the tree, so there are no provided values
+	l.RenderComponent(context.WithValue(context.Background(), l.CtxRenderComponent, render), consumer)
+
+	receive <- l.MessageWS{Message: []byte(`{"t":"e","i":"` + consumer.GetEventBindings()[0].ID + `"}`)}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case val := <-got:
+			if diff := deep.Equal("alice", val); diff != nil {
+				t.Error(diff)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("value not received")
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package hlive
 
 import (
+	"context"
 	"fmt"
 	"html"
 	"io"
@@ -175,6 +176,11 @@ func (r *Renderer) HTML(w io.Writer, el any) error {
 				return err
 			}
 		}
+	// Normally resolved by a pipeline
+	case ContextNodeBoxer:
+		if err := r.HTML(w, v.GetNodeContext(context.Background())); err != nil {
+			return err
+		}
 	// I don't think this is possible anymore
 	case []any:
 		if err := r.HTML(w, G(v...)); err != nil {
@@ -199,6 +205,12 @@ func (r *Renderer) HTML(w io.Writer, el any) error {
 func (r *Renderer) Attribute(attrs []Attributer, w io.Writer) error {
 	for i := 0; i < len(attrs); i++ {
 		attr := attrs[i]
+
+		// Only for the server
+		if attr.GetName() == AttrMemo {
+			continue
+		}
+
 		if attr.IsNoEscapeString() {
 			if _, err := w.Write([]byte(fmt.Sprintf(` %s="%s"`, attr.GetName(), attr.GetValue()))); err != nil {
 				return fmt.Errorf("write: %w", err)
@@ -0,0 +1,81 @@
+package hlive
+
+import (
+	"context"
+	"net/http"
+)
+
+const ctxRouterMatch CtxKey = "router_match"
+
+// Router maps URL path patterns to Pages. All Pages share one PageServer, so they have one PageSessionStore and
+// one WebSocket endpoint. Live navigation, see Nav, between the Pages is enabled.
+//
+// Patterns use the http.ServeMux syntax, including methods and wildcards. Use Request.PathValue to get the
+// wildcard values.
+type Router struct {
+	mux    *http.ServeMux
+	server *PageServer
+}
+
+func NewRouter() *Router {
+	return NewRouterWithSessionStore(NewPageSessionStore())
+}
+
+func NewRouterWithSessionStore(sess *PageSessionStore) *Router {
+	rt := &Router{
+		mux: http.NewServeMux(),
+	}
+
+	rt.server = newPageServer(rt.page, sess)
+	rt.server.Navigate = rt.page
+
+	return rt
+}
+
+// Handle adds a Page factory for the pattern. Return nil to respond with not found.
+func (rt *Router) Handle(pattern string, pf func(r *http.Request) *Page) {
+	rt.mux.HandleFunc(pattern, func(_ http.ResponseWriter, r *http.Request) {
+		if match, ok := r.Context().Value(ctxRouterMatch).(*routerMatch); ok {
+			match.page = pf(r)
+		}
+	})
+}
+
+// PageServer returns the PageServer used for all Pages, to allow for configuration
+func (rt *Router) PageServer() *PageServer {
+	return rt.server
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.server.ServeHTTP(w, r)
+}
+
+// page returns the Page for the request or nil when no pattern matches
+func (rt *Router) page(r *http.Request) *Page {
+	match := &routerMatch{}
+
+	// Let the mux do the matching, so the path values are set
+	rt.mux.ServeHTTP(&routerMatch{}, r.WithContext(context.WithValue(r.Context(), ctxRouterMatch, match)))
+
+	return match.page
+}
+
+// routerMatch captures the matched Page, it's also a ResponseWriter that discards the mux's responses
+type routerMatch struct {
+	page   *Page
+	header http.Header
+}
+
+func (m *routerMatch) Header() http.Header {
+	if m.header == nil {
+		m.header = http.Header{}
+	}
+
+	return m.header
+}
+
+func (m *routerMatch) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (m *routerMatch) WriteHeader(int) {}
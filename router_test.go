@@ -0,0 +1,78 @@
+package hlive_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/gorilla/websocket"
+)
+
+func newTestRouter() *l.Router {
+	rt := l.NewRouter()
+	rt.Handle("GET /users/{id}", func(r *http.Request) *l.Page {
+		page := l.NewPage()
+		page.DOM().Body().Add(l.T("p", "User "+r.PathValue("id")))
+
+		return page
+	})
+	rt.Handle("GET /about", func(r *http.Request) *l.Page {
+		page := l.NewPage()
+		page.DOM().Body().Add(l.T("p", "About"))
+
+		return page
+	})
+
+	return rt
+}
+
+func TestRouter_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	rt := newTestRouter()
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/5", nil))
+
+	if !strings.Contains(w.Body.String(), "User 5") {
+		t.Error("page not rendered:", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Error("expected not found, got:", w.Code)
+	}
+}
+
+func TestRouter_Navigate(t *testing.T) {
+	t.Parallel()
+
+	rt := newTestRouter()
+
+	ts := httptest.NewServer(rt)
+	t.Cleanup(ts.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/users/5?hlive=1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	waitForMessage(t, conn, "s|id|")
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"t":"n","d":{"u":"/about"}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForMessage(t, conn, base64.StdEncoding.EncodeToString([]byte("About")))
+
+	if rt.PageServer().Sessions.GetSessionCount() != 1 {
+		t.Error("expected one session")
+	}
+}
@@ -0,0 +1,132 @@
+package hlive
+
+import (
+	"context"
+	"sync"
+)
+
+// ContextNodeBoxer is a Node that's resolved using the render context.
+//
+// Unlike NodeBoxer, it's passed to the pipeline as is, so it can see the context of the render it's a part of.
+type ContextNodeBoxer interface {
+	GetNodeContext(ctx context.Context) any
+}
+
+// State is a LockBox that re-renders the Components that read it when its value changes.
+//
+// Add a State to a Component as a Node, and that Component will be rendered each time the value is set. You can also
+// call Read during a render or Mount to subscribe the Component being rendered. Set can be called from any goroutine,
+// a RenderComponent is scheduled for each subscribed Component, on every Page it's mounted on.
+//
+// Subscriptions are removed when the Page is closed or when a Teardowner Component is torn down.
+type State[T any] struct {
+	*LockBox[T]
+
+	readers map[stateReader]context.Context
+	mu      sync.Mutex
+}
+
+// A Component can be mounted on more than one Page, each with its own render context
+type stateReader struct {
+	comp Componenter
+	done <-chan struct{}
+}
+
+// NewState creates a new State value.
+func NewState[T any](val T) *State[T] {
+	return &State[T]{
+		LockBox: NewLockBox(val),
+		readers: map[stateReader]context.Context{},
+	}
+}
+
+// Set the value and render all subscribed Components.
+func (s *State[T]) Set(val T) {
+	s.LockBox.Set(val)
+	s.notify()
+}
+
+// Lock allows you to update the value while holding a lock, then renders all subscribed Components.
+func (s *State[T]) Lock(f func(val T) T) {
+	s.LockBox.Lock(f)
+	s.notify()
+}
+
+// Read returns the value and, if called during a WebSocket render, subscribes the closest Component to changes.
+func (s *State[T]) Read(ctx context.Context) T {
+	s.track(ctx)
+
+	return s.Get()
+}
+
+// GetNodeContext allows a State to be used as a Node.
+//
+// The value must be a valid Node.
+func (s *State[T]) GetNodeContext(ctx context.Context) any {
+	return s.Read(ctx)
+}
+
+func (s *State[T]) track(ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+
+	comp, ok := ctx.Value(CtxComponent).(Componenter)
+	if !ok {
+		return
+	}
+
+	// Only WebSocket renders can be rendered again
+	if _, ok := ctx.Value(CtxRenderComponent).(func(context.Context, Componenter)); !ok {
+		return
+	}
+
+	key := stateReader{comp: comp, done: ctx.Done()}
+
+	s.mu.Lock()
+	_, exists := s.readers[key]
+	s.readers[key] = ctx
+	s.mu.Unlock()
+
+	if exists {
+		return
+	}
+
+	// A way to remove the reader when you delete a Component
+	if td, ok := comp.(Teardowner); ok {
+		td.AddTeardown(func() {
+			s.mu.Lock()
+			delete(s.readers, key)
+			s.mu.Unlock()
+		})
+	}
+}
+
+func (s *State[T]) notify() {
+	type render struct {
+		ctx  context.Context //nolint:containedctx // we need the render context of each reader
+		comp Componenter
+	}
+
+	s.mu.Lock()
+
+	renders := make([]render, 0, len(s.readers))
+
+	for key, ctx := range s.readers {
+		// Page closed
+		if ctx.Err() != nil {
+			delete(s.readers, key)
+
+			continue
+		}
+
+		renders = append(renders, render{ctx: ctx, comp: key.comp})
+	}
+
+	s.mu.Unlock()
+
+	// Set can be called during a render, so we can't block
+	for i := 0; i < len(renders); i++ {
+		go RenderComponent(renders[i].ctx, renders[i].comp)
+	}
+}
@@ -0,0 +1,81 @@
+package hlive_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/go-test/deep"
+)
+
+func renderComponentContext(ctx context.Context) (context.Context, <-chan l.Componenter) {
+	rendered := make(chan l.Componenter, 1)
+
+	return context.WithValue(ctx, l.CtxRenderComponent, func(_ context.Context, comp l.Componenter) {
+		rendered <- comp
+	}), rendered
+}
+
+func TestState_SetRendersReader(t *testing.T) {
+	t.Parallel()
+
+	comp := l.C("div")
+	ctx, rendered := renderComponentContext(context.WithValue(context.Background(), l.CtxComponent, comp))
+
+	state := l.NewState(1)
+
+	if diff := deep.Equal(1, state.Read(ctx)); diff != nil {
+		t.Error(diff)
+	}
+
+	state.Set(2)
+
+	select {
+	case got := <-rendered:
+		if got != comp {
+			t.Error("unexpected component rendered")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("component not rendered")
+	}
+}
+
+func TestState_PipelineSubscribesClosestComponent(t *testing.T) {
+	t.Parallel()
+
+	state := l.NewState("value 1")
+	comp := l.C("div", l.T("span", state))
+
+	page := l.NewPage()
+	page.DOM().Body().Add(comp)
+
+	ctx, rendered := renderComponentContext(context.Background())
+
+	if _, err := page.RunDiffPipeline(ctx, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	state.Set("value 2")
+
+	select {
+	case got := <-rendered:
+		if got != comp {
+			t.Error("unexpected component rendered")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("component not rendered")
+	}
+}
+
+func TestState_NoRenderWithoutReaders(t *testing.T) {
+	t.Parallel()
+
+	state := l.NewState(1)
+	state.Set(2)
+
+	if diff := deep.Equal(2, state.Get()); diff != nil {
+		t.Error(diff)
+	}
+}
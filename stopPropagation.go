@@ -26,9 +26,9 @@ type StopPropagationAttribute struct {
 }
 
 func (a *StopPropagationAttribute) Initialize(page *Page) {
-	page.DOM().Head().Add(T("script", HTML(StopPropagationJavaScript)))
+	page.ScriptAdd(StopPropagationJavaScript)
 }
 
 func (a *StopPropagationAttribute) InitializeSSR(page *Page) {
-	page.DOM().Head().Add(T("script", HTML(StopPropagationJavaScript)))
+	page.ScriptAdd(StopPropagationJavaScript)
 }
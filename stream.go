@@ -0,0 +1,190 @@
+package hlive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const ctxSSR CtxKey = "ssr"
+
+// AttrLateHead marks a template with head nodes added after the head was streamed
+const AttrLateHead = "data-hlive-late-head"
+
+// PageOptionStreaming streams the server side render. The head is sent first, then each child of the body as it's
+// rendered. Head nodes added after the head was sent, like plugin scripts, are moved to the head by the browser.
+//
+// The Page's Cache isn't used when streaming. Each part is walked on its own, so the BeforeWalk and AfterWalk
+// functions of the PipelineSSR processors aren't called, use BeforeTagger, AfterTagger or OnSimpleNode instead.
+func PageOptionStreaming() func(*Page) {
+	return func(p *Page) {
+		p.streaming = true
+	}
+}
+
+// IsSSR returns true when the context is for a server side render
+func IsSSR(ctx context.Context) bool {
+	ssr, _ := ctx.Value(ctxSSR).(bool)
+
+	return ssr
+}
+
+// SSRPlaceholder renders the placeholder for server side renders, and the node once the WebSocket connects.
+// Use it for slow content so it doesn't hold up the first response.
+func SSRPlaceholder(placeholder, node any) ContextNodeBoxer {
+	return ssrPlaceholder{placeholder: placeholder, node: node}
+}
+
+type ssrPlaceholder struct {
+	placeholder any
+	node        any
+}
+
+func (s ssrPlaceholder) GetNodeContext(ctx context.Context) any {
+	if IsSSR(ctx) {
+		return s.placeholder
+	}
+
+	return s.node
+}
+
+// streamHTTP is the streaming version of the server side render
+func (p *Page) streamHTTP(ctx context.Context, w io.Writer) error {
+	ctx = context.WithValue(ctx, ctxSSR, true)
+
+	flush := func() {
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+
+	html, _ := p.dom.html.(Tagger)
+	head, _ := p.dom.head.(Tagger)
+	body, _ := p.dom.body.(Tagger)
+
+	if html == nil || head == nil || body == nil {
+		return errors.New("stream: dom root is not a tagger")
+	}
+
+	if err := p.renderer.HTML(w, p.dom.docType); err != nil {
+		return fmt.Errorf("stream doctype: %w", err)
+	}
+
+	if err := p.streamOpenTag(ctx, w, html); err != nil {
+		return fmt.Errorf("stream html: %w", err)
+	}
+
+	// Head, so the browser can start loading what it needs
+	headCount := len(head.GetNodes().Get())
+	scriptCount := p.scriptCount()
+
+	if err := p.streamNode(ctx, w, head); err != nil {
+		return fmt.Errorf("stream head: %w", err)
+	}
+
+	flush()
+
+	// Body
+	if err := p.streamOpenTag(ctx, w, body); err != nil {
+		return fmt.Errorf("stream body: %w", err)
+	}
+
+	kids := body.GetNodes().Get()
+	for i := 0; i < len(kids); i++ {
+		if err := p.streamNode(ctx, w, kids[i]); err != nil {
+			return fmt.Errorf("stream body node: %w", err)
+		}
+
+		flush()
+	}
+
+	// Head nodes added while streaming the body
+	late := G()
+
+	if headKids := head.GetNodes().Get(); len(headKids) > headCount {
+		late.Add(headKids[headCount:]...)
+	}
+
+	if p.assets != nil && p.scriptCount() != scriptCount {
+		late.Add(scriptBundle{page: p})
+	}
+
+	if len(late.Get()) != 0 {
+		if err := p.streamNode(ctx, w, T("template", Attrs{AttrLateHead: ""}, late)); err != nil {
+			return fmt.Errorf("stream late head: %w", err)
+		}
+	}
+
+	if _, err := w.Write([]byte("</body></html>")); err != nil {
+		return fmt.Errorf("stream close: %w", err)
+	}
+
+	return nil
+}
+
+// streamNode walks and renders a node, walks are repeated if a plugin changes the DOM
+func (p *Page) streamNode(ctx context.Context, w io.Writer, node any) error {
+	for {
+		tree, err := p.pipelineSSR.runNode(ctx, io.Discard, node)
+		if errors.Is(err, ErrDOMInvalidated) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return p.renderer.HTML(w, tree)
+	}
+}
+
+// streamOpenTag walks and renders just the open tag, the cache hash isn't used so it's removed
+func (p *Page) streamOpenTag(ctx context.Context, w io.Writer, tagger Tagger) error {
+	var attrs []any
+
+	tagAttrs := tagger.GetAttributes()
+	for i := 0; i < len(tagAttrs); i++ {
+		if tagAttrs[i].GetName() != PageHashAttr {
+			attrs = append(attrs, tagAttrs[i])
+		}
+	}
+
+	for {
+		tree, err := p.pipelineSSR.runNode(ctx, io.Discard, T(tagger.GetName(), attrs...))
+		if errors.Is(err, ErrDOMInvalidated) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		tag, ok := tree.(*Tag)
+		if !ok {
+			return errors.New("walked node is not a tag")
+		}
+
+		if _, err := w.Write([]byte("<" + tag.GetName())); err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+
+		if err := p.renderer.Attribute(tag.GetAttributes(), w); err != nil {
+			return fmt.Errorf("render attributes: %w", err)
+		}
+
+		if _, err := w.Write([]byte(">")); err != nil {
+			return fmt.Errorf("write: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func (p *Page) scriptCount() int {
+	p.muMeta.RLock()
+	defer p.muMeta.RUnlock()
+
+	return len(p.scripts)
+}
@@ -0,0 +1,135 @@
+package hlive_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/go-test/deep"
+)
+
+func TestStreaming_SameAsRender(t *testing.T) {
+	t.Parallel()
+
+	newPage := func(options ...l.PageOption) *l.Page {
+		page := l.NewPage(options...)
+		page.DOM().Title().Add("Stream")
+		page.DOM().Body().Add(l.T("h1", "Hello"), "text", l.C("p", "World"))
+
+		return page
+	}
+
+	want := httptest.NewRecorder()
+	newPage().ServeHTTP(want, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	got := httptest.NewRecorder()
+	newPage(l.PageOptionStreaming()).ServeHTTP(got, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if diff := deep.Equal(want.Body.String(), got.Body.String()); diff != nil {
+		t.Error(diff)
+	}
+
+	if !got.Flushed {
+		t.Error("not flushed")
+	}
+}
+
+func TestStreaming_LateHead(t *testing.T) {
+	t.Parallel()
+
+	page := l.NewPage(l.PageOptionStreaming())
+	page.DOM().Body().Add(l.T("form", l.PreventDefault()))
+
+	w := httptest.NewRecorder()
+	page.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	body := w.Body.String()
+	head, rest, _ := strings.Cut(body, "</head>")
+
+	if strings.Contains(head, l.PreventDefaultAttributeName) {
+		t.Error("plugin script in streamed head")
+	}
+
+	if !strings.Contains(rest, `<template `+l.AttrLateHead+`=""><script>`) {
+		t.Error("late head not rendered:", rest)
+	}
+
+	if !strings.HasSuffix(body, "</template></body></html>") {
+		t.Error("unexpected end:", body)
+	}
+}
+
+func TestSSRPlaceholder(t *testing.T) {
+	t.Parallel()
+
+	page := l.NewPage()
+	page.DOM().Body().Add(l.SSRPlaceholder(l.T("p", "Loading"), l.T("p", "Loaded")))
+
+	buf := bytes.NewBuffer(nil)
+	if _, err := page.RunRenderPipeline(context.Background(), buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "Loading") {
+		t.Error("placeholder not rendered")
+	}
+
+	buf.Reset()
+
+	tree, err := page.RunDiffPipeline(context.Background(), buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.NewRenderer().HTML(buf, tree); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "Loaded") {
+		t.Error("node not rendered")
+	}
+}
+
+func TestStreaming_NoWalkHooks(t *testing.T) {
+	t.Parallel()
+
+	var walks, taggers int
+
+	page := l.NewPage(l.PageOptionStreaming())
+	page.DOM().Body().Add(l.T("p", "Hello"))
+
+	pp := l.NewPipelineProcessor("test_walk")
+	pp.BeforeWalk = func(_ context.Context, _ io.Writer, node *l.NodeGroup) (*l.NodeGroup, error) {
+		walks++
+
+		return node, nil
+	}
+	pp.AfterWalk = func(_ context.Context, _ io.Writer, node *l.NodeGroup) (*l.NodeGroup, error) {
+		walks++
+
+		return node, nil
+	}
+	pp.BeforeTagger = func(_ context.Context, _ io.Writer, tag l.Tagger) (l.Tagger, error) {
+		if tag.GetName() == "p" {
+			taggers++
+		}
+
+		return tag, nil
+	}
+	page.PipelineSSR().Add(pp)
+
+	page.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if diff := deep.Equal(0, walks); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(1, taggers); diff != nil {
+		t.Error(diff)
+	}
+}
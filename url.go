@@ -0,0 +1,132 @@
+package hlive
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+var (
+	// ErrURLNotSameOrigin is returned when trying to push or replace a URL for a different origin
+	ErrURLNotSameOrigin = errors.New("url must be a path on the same origin")
+	// ErrNoPage is returned when trying to push or replace a URL with a context that doesn't have a Page
+	ErrNoPage = errors.New("page not found in context")
+)
+
+// OnPopState binds a handler to the browser's popstate event, which happens when the user goes back or forward
+// to a URL added by PushURL. Event.Value is the new URL.
+func OnPopState(handler EventHandler) *EventBinding {
+	return On("popstate", handler)
+}
+
+// URL returns a copy of the URL the browser is showing for this Page
+func (p *Page) URL() *url.URL {
+	p.muMeta.RLock()
+	defer p.muMeta.RUnlock()
+
+	if p.url == nil {
+		return &url.URL{Path: "/"}
+	}
+
+	u := *p.url
+
+	return &u
+}
+
+// The query params page.js adds to the WebSocket URL, they're not part of the browser's URL
+var connectionParams = []string{"hlive", "hhash", "hcsrf", "hnonce"}
+
+func (p *Page) setURL(u *url.URL) {
+	pu := &url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery, Fragment: u.Fragment}
+
+	// Remove our connection params
+	q := u.Query()
+	removed := false
+
+	for i := 0; i < len(connectionParams); i++ {
+		if q.Has(connectionParams[i]) {
+			q.Del(connectionParams[i])
+			removed = true
+		}
+	}
+
+	if removed {
+		pu.RawQuery = q.Encode()
+	}
+
+	p.muMeta.Lock()
+	p.url = pu
+	p.muMeta.Unlock()
+}
+
+// URLFromContext returns the URL the browser is showing for the current Page
+func URLFromContext(ctx context.Context) (*url.URL, bool) {
+	page, ok := PageFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	return page.URL(), true
+}
+
+// QueryFromContext returns the query parameters of the URL the browser is showing for the current Page
+func QueryFromContext(ctx context.Context) url.Values {
+	u, ok := URLFromContext(ctx)
+	if !ok {
+		return url.Values{}
+	}
+
+	return u.Query()
+}
+
+// PushURL adds a URL to the browser's history without reloading the page.
+// The URL can be relative to the current URL but must be on the same origin.
+func PushURL(ctx context.Context, u string) error {
+	return historyUpdate(ctx, "p", u)
+}
+
+// ReplaceURL replaces the browser's current URL without reloading the page.
+// The URL can be relative to the current URL but must be on the same origin.
+func ReplaceURL(ctx context.Context, u string) error {
+	return historyUpdate(ctx, "r", u)
+}
+
+func historyUpdate(ctx context.Context, action, u string) error {
+	page, ok := PageFromContext(ctx)
+	if !ok {
+		return ErrNoPage
+	}
+
+	next, err := page.URL().Parse(u)
+	if err != nil {
+		return fmt.Errorf("parse url: %w", err)
+	}
+
+	if next.Scheme != "" || next.Host != "" {
+		return ErrURLNotSameOrigin
+	}
+
+	page.setURL(next)
+
+	// Only when connected
+	if ctx.Value(CtxRender) == nil {
+		return nil
+	}
+
+	page.wsSend(ctx, "n|"+action+"|"+base64.StdEncoding.EncodeToString([]byte(page.URL().String())))
+
+	return nil
+}
+
+func (p *Page) updateURL(raw string) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		p.logger.Error("url update: parse", "error", err, "url", raw)
+
+		return
+	}
+
+	p.setURL(u)
+}
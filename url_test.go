@@ -0,0 +1,120 @@
+package hlive_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	l "github.com/SamHennessy/hlive"
+	"github.com/go-test/deep"
+	"github.com/gorilla/websocket"
+)
+
+func TestURL_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	page := l.NewPage()
+	page.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/list?q=a", nil))
+
+	if diff := deep.Equal("/list?q=a", page.URL().String()); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestURL_PushURL(t *testing.T) {
+	t.Parallel()
+
+	page := l.NewPage()
+	page.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/list?q=a", nil))
+
+	ctx := context.WithValue(context.Background(), l.CtxPage, page)
+
+	if err := l.PushURL(ctx, "?q=b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal("b", l.QueryFromContext(ctx).Get("q")); diff != nil {
+		t.Error(diff)
+	}
+
+	if err := l.ReplaceURL(ctx, "https://example.com/"); !errors.Is(err, l.ErrURLNotSameOrigin) {
+		t.Error("expected same origin error, got:", err)
+	}
+
+	if err := l.PushURL(context.Background(), "?q=c"); !errors.Is(err, l.ErrNoPage) {
+		t.Error("expected no page error, got:", err)
+	}
+}
+
+func TestURL_PushURLFromEvent(t *testing.T) {
+	t.Parallel()
+
+	mounted := make(chan struct{})
+	binding := l.On("click", func(ctx context.Context, _ l.Event) {
+		_ = l.PushURL(ctx, "?q=b")
+	})
+
+	s := l.NewPageServer(func() *l.Page {
+		page := l.NewPage()
+		page.DOM().Body().Add(l.C("button", binding))
+		page.HookMountAdd(func(_ context.Context, _ *l.Page) {
+			close(mounted)
+		})
+
+		return page
+	})
+
+	conn := dialPageServer(t, s)
+
+	select {
+	case <-mounted:
+	case <-time.After(time.Second):
+		t.Fatal("page not mounted")
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"t":"e","i":"`+binding.ID+`"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForMessage(t, conn, "n|p|"+base64.StdEncoding.EncodeToString([]byte("/?q=b")))
+}
+
+func TestURL_ConnectionParamsRemoved(t *testing.T) {
+	t.Parallel()
+
+	urls := make(chan string, 1)
+
+	s := l.NewPageServer(func() *l.Page {
+		page := l.NewPage()
+		page.HookMountAdd(func(_ context.Context, page *l.Page) {
+			urls <- page.URL().String()
+		})
+
+		return page
+	})
+
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial(
+		"ws"+strings.TrimPrefix(ts.URL, "http")+"/list?q=a&hlive=1&hhash=h&hcsrf=token&hnonce=n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	select {
+	case u := <-urls:
+		if diff := deep.Equal("/list?q=a", u); diff != nil {
+			t.Error(diff)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("page not mounted")
+	}
+}